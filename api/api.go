@@ -2,15 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"smart-scheduler/apperr"
 )
 
-type ErrorResponse struct {
-	Message string `json:"message"`
-}
-
 func SuccessJson(w http.ResponseWriter, r *http.Request, data interface{}) {
 	jsonMsg, err := json.Marshal(data)
 	if err != nil {
@@ -36,37 +34,80 @@ func Success(w http.ResponseWriter, r *http.Request, jsonMsg []byte) {
 	)
 }
 
-func Error(w http.ResponseWriter, r *http.Request, err error, code int) {
-	if code == 0 {
-		code = toHTTPStatusCode(err)
+// Created answers 201 with data as JSON and, when location is set, a
+// Location header pointing at the new record. Headers are all set before
+// the status is written.
+func Created(w http.ResponseWriter, r *http.Request, location string, data interface{}) {
+	jsonMsg, err := json.Marshal(data)
+	if err != nil {
+		Error(w, r, fmt.Errorf("serialising response failed: %w", err), 500)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.WriteHeader(code)
+	if location != "" {
+		w.Header().Set("Location", location)
+	}
+	w.WriteHeader(http.StatusCreated)
+	if _, err := w.Write(jsonMsg); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+
+	log.Printf(
+		"%s %s %s 201",
+		r.Method,
+		r.RequestURI,
+		r.RemoteAddr,
+	)
+}
+
+// Error writes err as an RFC 9457 problem. An error the service
+// classified is answered with the status of its kind; code is the status
+// for anything else, with 0 meaning 500.
+func Error(w http.ResponseWriter, r *http.Request, err error, code int) {
 	if err == nil {
 		err = fmt.Errorf("nil err")
 	}
-	logErr := err
-	errorMsgJSON, err := json.Marshal(ErrorResponse{Message: err.Error()})
-	if err != nil {
-		log.Println(err)
-	} else {
-		if _, err = w.Write(errorMsgJSON); err != nil {
-			log.Printf("Error writing response: %v", err)
-		}
+	kind := problemFor(err, code)
+	code = kind.Status
+	problem := Problem{
+		Type:      problemTypeBase + kind.Code,
+		Title:     kind.Title,
+		Status:    code,
+		Detail:    err.Error(),
+		Instance:  r.URL.Path,
+		Code:      kind.Code,
+		RequestID: RequestID(w, r),
+	}
+	var invalid *apperr.ValidationError
+	if errors.As(err, &invalid) {
+		problem.Errors = invalid.Fields
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(code)
+	problemJSON, marshalErr := json.Marshal(problem)
+	if marshalErr != nil {
+		log.Println(marshalErr)
+	} else if _, writeErr := w.Write(problemJSON); writeErr != nil {
+		log.Printf("Error writing response: %v", writeErr)
 	}
 
 	log.Printf(
-		"%s %s %s %d %s",
+		"%s %s %s %d %s [%s] %s",
 		r.Method,
 		r.RequestURI,
 		r.RemoteAddr,
 		code,
-		logErr.Error(),
+		kind.Code,
+		problem.RequestID,
+		err.Error(),
 	)
 }
 
+// toHTTPStatusCode is the status Error answers err with when the handler
+// passes 0.
 func toHTTPStatusCode(err error) int {
-	// Add more specific error handling as needed
-	return http.StatusInternalServerError
+	return problemFor(err, 0).Status
 }
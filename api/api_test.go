@@ -3,11 +3,19 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"smart-scheduler/apperr"
 	"testing"
 )
 
+// Errors of the kinds the service defines, without importing it
+var (
+	errUserNotFound = apperr.New(apperr.Kind{Code: "user_not_found", Title: "User not found", Status: http.StatusNotFound}, "user not found")
+	errNoSlotFound  = apperr.New(apperr.Kind{Code: "no_slot_found", Title: "No available time slot", Status: http.StatusConflict}, "no available time slot found")
+)
+
 func TestSuccessJson(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -117,8 +125,8 @@ func TestError(t *testing.T) {
 
 			// Check content type
 			contentType := w.Header().Get("Content-Type")
-			if contentType != "application/json" {
-				t.Errorf("Expected Content-Type application/json, got %s", contentType)
+			if contentType != ProblemContentType {
+				t.Errorf("Expected Content-Type application/problem+json, got %s", contentType)
 			}
 
 			// Check CORS header
@@ -128,22 +136,45 @@ func TestError(t *testing.T) {
 			}
 
 			// Verify response is valid JSON with error structure
-			var errorResponse ErrorResponse
+			var errorResponse Problem
 			if err := json.Unmarshal(w.Body.Bytes(), &errorResponse); err != nil {
 				t.Errorf("Error response is not valid JSON: %v", err)
 			}
 
 			// Check error message
-			if tt.err != nil && errorResponse.Message != tt.err.Error() {
-				t.Errorf("Expected error message '%s', got '%s'", tt.err.Error(), errorResponse.Message)
+			if tt.err != nil && errorResponse.Detail != tt.err.Error() {
+				t.Errorf("Expected error message '%s', got '%s'", tt.err.Error(), errorResponse.Detail)
 			}
-			if tt.err == nil && errorResponse.Message != "nil err" {
-				t.Errorf("Expected 'nil err' message for nil error, got '%s'", errorResponse.Message)
+			if tt.err == nil && errorResponse.Detail != "nil err" {
+				t.Errorf("Expected 'nil err' message for nil error, got '%s'", errorResponse.Detail)
 			}
 		})
 	}
 }
 
+func TestCreated(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/v1/users", nil)
+	w := httptest.NewRecorder()
+	Created(w, req, "/api/v1/users/user9", map[string]string{"userCode": "user9"})
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", contentType)
+	}
+	if location := w.Header().Get("Location"); location != "/api/v1/users/user9" {
+		t.Errorf("Expected the Location header, got %q", location)
+	}
+
+	// A response that cannot be serialised is a 500, not a 201
+	w = httptest.NewRecorder()
+	Created(w, req, "/api/v1/users/user9", map[string]interface{}{"bad": make(chan int)})
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Location") != "" {
+		t.Errorf("Expected a plain 500, got %d with Location %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
 func TestSuccess(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -211,6 +242,31 @@ func TestToHTTPStatusCode(t *testing.T) {
 			err:            nil,
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:           "Validation error",
+			err:            &apperr.ValidationError{Fields: []apperr.FieldError{{Path: "durationMinutes"}}},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Unknown user",
+			err:            fmt.Errorf("%w: user9", errUserNotFound),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "No slot found",
+			err:            fmt.Errorf("%w for all participants", errNoSlotFound),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Malformed body",
+			err:            &apperr.ValidationError{Malformed: true},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Storage failure",
+			err:            &apperr.StorageError{Err: errors.New("connection refused")},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
 	}
 
 	for _, tt := range tests {
@@ -223,22 +279,105 @@ func TestToHTTPStatusCode(t *testing.T) {
 	}
 }
 
-func TestErrorResponseStructure(t *testing.T) {
-	// Test ErrorResponse struct
-	err := ErrorResponse{Message: "test error"}
+func TestErrorListsFieldErrors(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/v1/schedule", nil)
+	w := httptest.NewRecorder()
+	Error(w, req, &apperr.ValidationError{Fields: []apperr.FieldError{
+		{Path: "timeRange.start", Code: apperr.CodeInvalidFormat, Message: "must be an RFC 3339 time"},
+	}}, 0)
 
-	jsonData, marshalErr := json.Marshal(err)
-	if marshalErr != nil {
-		t.Errorf("Failed to marshal ErrorResponse: %v", marshalErr)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", w.Code)
+	}
+	var errorResponse Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &errorResponse); err != nil {
+		t.Fatalf("Error response is not valid JSON: %v", err)
+	}
+	if len(errorResponse.Errors) != 1 || errorResponse.Errors[0].Path != "timeRange.start" || errorResponse.Errors[0].Code != apperr.CodeInvalidFormat {
+		t.Errorf("Expected the field error in the response, got %+v", errorResponse.Errors)
+	}
+}
+
+func TestProblemFields(t *testing.T) {
+	handler := WithRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Error(w, r, fmt.Errorf("%w for all participants", errNoSlotFound), 0)
+	}))
+	req := httptest.NewRequest("POST", "/api/v1/schedule?dryRun=1", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var problem map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Error response is not valid JSON: %v", err)
+	}
+	expected := map[string]interface{}{
+		"type":      "/problems/no_slot_found",
+		"title":     "No available time slot",
+		"status":    float64(http.StatusConflict),
+		"detail":    "no available time slot found for all participants",
+		"instance":  "/api/v1/schedule",
+		"code":      "no_slot_found",
+		"requestId": "abc-123",
+	}
+	for key, value := range expected {
+		if problem[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, problem[key])
+		}
+	}
+	if got := w.Header().Get(RequestIDHeader); got != "abc-123" {
+		t.Errorf("Expected the request ID to be echoed, got %q", got)
+	}
+}
+
+func TestProblemCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"Unknown user", fmt.Errorf("%w: user9", errUserNotFound), 0, "user_not_found"},
+		{"Validation", &apperr.ValidationError{}, 0, "validation_failed"},
+		{"Malformed body", &apperr.ValidationError{Malformed: true}, 0, "bad_request"},
+		{"Storage", &apperr.StorageError{Err: errors.New("timeout")}, 0, "storage_unavailable"},
+		{"Classified with another status", &apperr.StorageError{Err: errors.New("timeout")}, http.StatusBadRequest, "storage_unavailable"},
+		{"Unclassified with status", errors.New("bad page size"), http.StatusBadRequest, "bad_request"},
+		{"Unclassified", errors.New("boom"), 0, "internal_error"},
+		{"Unlisted status", errors.New("slow down"), http.StatusTooManyRequests, "too_many_requests"},
 	}
 
-	var unmarshaled ErrorResponse
-	if unmarshalErr := json.Unmarshal(jsonData, &unmarshaled); unmarshalErr != nil {
-		t.Errorf("Failed to unmarshal ErrorResponse: %v", unmarshalErr)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Error(w, httptest.NewRequest("GET", "/test", nil), tt.err, tt.status)
+
+			var problem Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("Error response is not valid JSON: %v", err)
+			}
+			if problem.Code != tt.code || problem.Type != "/problems/"+tt.code || problem.Status != w.Code {
+				t.Errorf("Expected code %s, got %+v", tt.code, problem)
+			}
+			if problem.RequestID == "" || w.Header().Get(RequestIDHeader) != problem.RequestID {
+				t.Errorf("Expected a request ID in the body and header, got %q and %q", problem.RequestID, w.Header().Get(RequestIDHeader))
+			}
+		})
 	}
+}
 
-	if unmarshaled.Message != "test error" {
-		t.Errorf("Expected message 'test error', got '%s'", unmarshaled.Message)
+func TestWithRequestIDReplacesInvalidIDs(t *testing.T) {
+	var seen string
+	handler := WithRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(w, r)
+	}))
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set(RequestIDHeader, "not valid\nat all")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if seen == "" || seen == req.Header.Get(RequestIDHeader) || w.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("Expected a fresh request ID, got %q", seen)
 	}
 }
 
@@ -262,8 +401,8 @@ func TestAPIResponseHeaders(t *testing.T) {
 		w := httptest.NewRecorder()
 		Error(w, req, errors.New("test"), http.StatusBadRequest)
 
-		if w.Header().Get("Content-Type") != "application/json" {
-			t.Error("Error should set Content-Type to application/json")
+		if w.Header().Get("Content-Type") != ProblemContentType {
+			t.Error("Error should set Content-Type to application/problem+json")
 		}
 		if w.Header().Get("Access-Control-Allow-Origin") != "*" {
 			t.Error("Error should set CORS header")
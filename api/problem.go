@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"smart-scheduler/apperr"
+	"strings"
+)
+
+// ProblemContentType is the media type of error responses (RFC 9457).
+const ProblemContentType = "application/problem+json"
+
+// RequestIDHeader carries the request ID in both directions. A client may
+// send its own so its logs and ours line up.
+const RequestIDHeader = "X-Request-ID"
+
+// problemTypeBase prefixes a problem's code to form its type. The types
+// are listed in the README.
+const problemTypeBase = "/problems/"
+
+// Problem is the body of every error response. Code is a stable,
+// machine-readable name for the kind of error. Type is the same code as a
+// URI reference, as the RFC asks for.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId"`
+	// Every invalid field, when the request failed validation
+	Errors []apperr.FieldError `json:"errors,omitempty"`
+}
+
+// statusProblems describe errors the service did not classify, by the
+// status the handler chose.
+var statusProblems = map[int]apperr.Kind{
+	http.StatusBadRequest:           {Code: "bad_request", Title: "Bad request"},
+	http.StatusNotFound:             {Code: "not_found", Title: "Not found"},
+	http.StatusMethodNotAllowed:     {Code: "method_not_allowed", Title: "Method not allowed"},
+	http.StatusConflict:             {Code: "conflict", Title: "Conflict"},
+	http.StatusUnprocessableEntity:  {Code: "validation_failed", Title: "Invalid request"},
+	http.StatusServiceUnavailable:   {Code: "storage_unavailable", Title: "Storage unavailable"},
+	http.StatusInternalServerError:  {Code: "internal_error", Title: "Internal server error"},
+	http.StatusUnsupportedMediaType: {Code: "unsupported_media_type", Title: "Unsupported media type"},
+}
+
+// problemFor classifies err. An error that knows its kind decides both the
+// code and the status; anything else is described by the status the
+// handler chose, or 500 when it chose none.
+func problemFor(err error, status int) apperr.Kind {
+	if kind, ok := apperr.Of(err); ok {
+		return kind
+	}
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	kind, ok := statusProblems[status]
+	if !ok {
+		title := http.StatusText(status)
+		kind = apperr.Kind{Code: strings.ReplaceAll(strings.ToLower(title), " ", "_"), Title: title}
+	}
+	kind.Status = status
+	return kind
+}
+
+type requestIDKey struct{}
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// WithRequestID gives every request an ID, taken from the client's
+// X-Request-ID header when it sent a sensible one, and echoes it in the
+// response.
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestID returns the ID WithRequestID gave r. Requests that did not go
+// through it get a fresh ID, which is also set on the response.
+func RequestID(w http.ResponseWriter, r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	id := newRequestID()
+	w.Header().Set(RequestIDHeader, id)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,111 @@
+// Package apperr holds the errors the service returns and the HTTP layer
+// reports. Both import it, so neither has to import the other.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Kind classifies an error for clients: a stable machine-readable code, a
+// short title and the status it is answered with. Codes are part of the
+// API: add new ones, but never rename them.
+type Kind struct {
+	Code   string
+	Title  string
+	Status int
+}
+
+// Classified is implemented by errors that know their Kind.
+type Classified interface {
+	error
+	Kind() Kind
+}
+
+// Of returns the Kind of the first classified error in err's chain.
+func Of(err error) (Kind, bool) {
+	var classified Classified
+	if errors.As(err, &classified) {
+		return classified.Kind(), true
+	}
+	return Kind{}, false
+}
+
+type sentinel struct {
+	kind Kind
+	msg  string
+}
+
+func (e *sentinel) Error() string { return e.msg }
+func (e *sentinel) Kind() Kind    { return e.kind }
+
+// New returns a sentinel error of the given kind, to be compared with
+// errors.Is and wrapped with fmt.Errorf("%w: ...").
+func New(kind Kind, msg string) error {
+	return &sentinel{kind: kind, msg: msg}
+}
+
+// Field error codes. Clients branch on these rather than on the message.
+const (
+	CodeRequired      = "required"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidValue  = "invalid_value"
+	CodeOutOfRange    = "out_of_range"
+	CodeDuplicate     = "duplicate"
+	CodeNotSupported  = "not_supported"
+)
+
+// FieldError describes one problem with a request field. Path is the
+// field's JSON path, such as "timeRange.start" or "userIDs[2]".
+type FieldError struct {
+	Path    string `json:"path"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem found in a request, so the caller
+// can fix them all in one go. Malformed marks a body that could not be
+// decoded at all, answered with 400 rather than 422.
+type ValidationError struct {
+	Fields    []FieldError
+	Malformed bool
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		problems = append(problems, f.Path+": "+f.Message)
+	}
+	return "invalid request: " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) Kind() Kind {
+	if e.Malformed {
+		return Kind{Code: "bad_request", Title: "Bad request", Status: http.StatusBadRequest}
+	}
+	return Kind{Code: "validation_failed", Title: "Invalid request", Status: http.StatusUnprocessableEntity}
+}
+
+// Invalid is a ValidationError for a single field.
+func Invalid(path, code, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Path: path, Code: code, Message: message}}}
+}
+
+// StorageError reports that the store could not be read or written, as
+// opposed to anything being wrong with the request.
+type StorageError struct {
+	Err error
+}
+
+func (e *StorageError) Error() string {
+	return "storage failure: " + e.Err.Error()
+}
+
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
+func (e *StorageError) Kind() Kind {
+	return Kind{Code: "storage_unavailable", Title: "Storage unavailable", Status: http.StatusServiceUnavailable}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"smart-scheduler/config"
+	"smart-scheduler/service"
+)
+
+// runImport handles "import -user <id> [-source <name>] <file.ics|->",
+// reading the file into the user's calendar in the configured database.
+func runImport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	userId := flags.String("user", "", "user whose calendar receives the events")
+	source := flags.String("source", service.DefaultImportSource, "name of the feed, re-imports of the same source update in place")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *userId == "" || flags.NArg() != 1 {
+		return errors.New("usage: smart-scheduler import -user <id> [-source <name>] <file.ics|->")
+	}
+	if cfg.DBDriver == "memory" {
+		return errors.New("importing into the memory store would be lost on exit, set DB_DRIVER")
+	}
+
+	var in io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	service.SetStore(openStore(cfg))
+	result, err := service.ImportCalendar(*userId, *source, in)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %s for %s: %d created, %d updated, %d unchanged, %d removed\n",
+		result.Source, *userId, result.Created, result.Updated, result.Unchanged, result.Removed)
+	for _, skip := range result.Skipped {
+		fmt.Printf("Skipped %s: %s\n", skip.UID, skip.Reason)
+	}
+	return nil
+}
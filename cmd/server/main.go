@@ -3,30 +3,115 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
+	"smart-scheduler/api"
 	"smart-scheduler/config"
 	"smart-scheduler/db"
+	"smart-scheduler/notify"
 	"smart-scheduler/repository"
 	"smart-scheduler/routes"
+	"smart-scheduler/scoring"
+	"smart-scheduler/service"
+	_ "time/tzdata" // embed IANA zones for user profiles
 )
 
 func main() {
 	// Load configuration
 	cfg := config.Load()
 
-	// Initialize database
-	db.InitDB(cfg.DatabaseURL, cfg.DBName)
+	// "migrate up|down|status" manages the schema and "import" reads a
+	// .ics file into a calendar, then both exit
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "migrate":
+			err = runMigrate(cfg, os.Args[2:])
+		case "import":
+			err = runImport(cfg, os.Args[2:])
+		default:
+			log.Fatalf("Unknown command %q, expected migrate or import", os.Args[1])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Initialize storage
+	store := openStore(cfg)
+	service.SetStore(store)
 
 	// Populate dummy data for testing
-	if err := repository.CreateDummyData(db.DB); err != nil {
+	if err := repository.CreateDummyData(store); err != nil {
 		log.Printf("Warning: Failed to create dummy data: %v", err)
 	} else {
 		log.Println("Dummy data created successfully")
 	}
 
+	// Server-wide scoring rules, tunable per organization and overridable
+	// per request
+	weights, err := scoring.ParseWeights(cfg.ScoringWeights)
+	if err != nil {
+		log.Fatalf("Invalid SCORING_WEIGHTS: %v", err)
+	}
+	if err := service.SetDefaultScoring(repository.ScoringOptions{Profile: cfg.ScoringProfile, Weights: weights}); err != nil {
+		log.Fatalf("Invalid scoring configuration: %v", err)
+	}
+	orgs, err := scoring.ParseOrganizations(cfg.ScoringOrganizations)
+	if err != nil {
+		log.Fatalf("Invalid SCORING_ORGANIZATIONS: %v", err)
+	}
+	if err := service.SetOrganizationScoring(orgs); err != nil {
+		log.Fatalf("Invalid scoring configuration: %v", err)
+	}
+	if err := service.SetSlotDefaults(cfg.SlotStepMinutes, cfg.SlotAlign); err != nil {
+		log.Fatalf("Invalid SLOT_STEP_MINUTES: %v", err)
+	}
+
+	// Email attendees when meetings are booked, moved or cancelled
+	service.SetMailer(openMailer(cfg), cfg.MailFrom)
+
+	// Release tentative holds that were never confirmed
+	service.StartHoldSweeper(cfg.HoldSweepInterval, make(chan struct{}))
+
 	// Setup routes
 	router := routes.SetupRoutes()
 
 	// Start server
 	log.Printf("Server starting on port %s", cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, router))
+	log.Fatal(http.ListenAndServe(":"+cfg.Port, api.WithRequestID(router)))
+}
+
+// openStore connects the storage backend selected by DB_DRIVER.
+func openStore(cfg *config.Config) repository.Store {
+	switch cfg.DBDriver {
+	case "postgres", "sqlite":
+		db.InitDB(cfg.DBDriver, cfg.DatabaseURL, cfg.DBName)
+		return repository.NewGormStore(db.DB)
+	case "memory":
+		log.Println("Using in-memory storage, data is lost on restart")
+		return repository.NewMemoryStore()
+	default:
+		log.Fatalf("Unknown DB_DRIVER %q, expected postgres, sqlite or memory", cfg.DBDriver)
+		return nil
+	}
+}
+
+// openMailer returns the invitation mailer selected by MAILER, or nil when
+// invitations are turned off.
+func openMailer(cfg *config.Config) notify.Mailer {
+	switch cfg.Mailer {
+	case "log":
+		return notify.LogMailer{}
+	case "file":
+		log.Printf("Writing meeting invitations to %s", cfg.MailDir)
+		return &notify.FileMailer{Dir: cfg.MailDir}
+	case "smtp":
+		return &notify.SMTPMailer{Addr: cfg.SMTPAddr, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword}
+	case "none":
+		return nil
+	default:
+		log.Fatalf("Unknown MAILER %q, expected log, file, smtp or none", cfg.Mailer)
+		return nil
+	}
 }
@@ -1,18 +1,70 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 type Config struct {
+	DBDriver    string // "postgres", "sqlite" or "memory"
 	DatabaseURL string
 	Port        string
 	DBName      string
+
+	// How often expired tentative holds are released
+	HoldSweepInterval time.Duration
+
+	// Server-wide slot scoring: a profile name and "rule=weight" overrides
+	ScoringProfile string
+	ScoringWeights string
+	// Scoring options for each organization as JSON, such as
+	// {"research": {"profile": "focus"}}
+	ScoringOrganizations string
+
+	// Spacing of candidate meeting starts: 5, 10, 15, 30 or 60 minutes,
+	// aligned to wall-clock boundaries in the organizer's time zone or not
+	SlotStepMinutes int
+	SlotAlign       bool
+
+	// Meeting invitations: "log", "file", "smtp" or "none"
+	Mailer       string
+	MailFrom     string
+	MailDir      string // where the file mailer writes .eml files
+	SMTPAddr     string // host:port
+	SMTPUsername string
+	SMTPPassword string
 }
 
 func Load() *Config {
+	driver := getEnvOrDefault("DB_DRIVER", "postgres")
+	defaultURL := "host=localhost user=myuser dbname=meetingschedular port=5432 password=mypassword sslmode=disable"
+	if driver == "sqlite" {
+		// DATABASE_URL is the database file for SQLite
+		defaultURL = "smart-scheduler.db"
+	}
+
 	return &Config{
-		DatabaseURL: getEnvOrDefault("DATABASE_URL", "host=localhost user=myuser dbname=meetingschedular port=5432 password=mypassword sslmode=disable"),
+		DBDriver:    driver,
+		DatabaseURL: getEnvOrDefault("DATABASE_URL", defaultURL),
 		Port:        getEnvOrDefault("PORT", "8080"),
 		DBName:      getEnvOrDefault("DBNAME", "meetingschedular"),
+
+		HoldSweepInterval: getDurationOrDefault("HOLD_SWEEP_INTERVAL", time.Minute),
+
+		ScoringProfile:       getEnvOrDefault("SCORING_PROFILE", "default"),
+		ScoringWeights:       os.Getenv("SCORING_WEIGHTS"),
+		ScoringOrganizations: os.Getenv("SCORING_ORGANIZATIONS"),
+
+		SlotStepMinutes: getIntOrDefault("SLOT_STEP_MINUTES", 30),
+		SlotAlign:       getBoolOrDefault("SLOT_ALIGN", true),
+
+		Mailer:       getEnvOrDefault("MAILER", "log"),
+		MailFrom:     getEnvOrDefault("MAIL_FROM", "Smart Scheduler <scheduler@localhost>"),
+		MailDir:      getEnvOrDefault("MAIL_DIR", "mail"),
+		SMTPAddr:     getEnvOrDefault("SMTP_ADDR", "localhost:25"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
 	}
 }
 
@@ -23,6 +75,33 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getBoolOrDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
 func GetDSN() string {
 	return os.Getenv("DATABASE_URL")
 }
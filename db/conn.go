@@ -1,10 +1,15 @@
 package db
 
 import (
+	"fmt"
 	"log"
-	"smart-scheduler/model"
+	"net/url"
+	"reflect"
+	"regexp"
 	"sync"
+	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -12,36 +17,170 @@ import (
 var DB *gorm.DB
 var once sync.Once
 
-func InitDB(dburl string, dbName string) {
+// InitDB connects DB to the database for driver, "postgres" or "sqlite",
+// and exits when that fails.
+func InitDB(driver, dburl, dbName string) {
 	once.Do(func() {
-		// First connect to default postgres database to create our target database
-		defaultURL := "host=localhost user=myuser dbname=postgres port=5432 password=mypassword sslmode=disable"
-		defaultDB, err := gorm.Open(postgres.Open(defaultURL), &gorm.Config{})
+		var err error
+		DB, err = Open(driver, dburl, dbName)
 		if err != nil {
-			log.Printf("failed to connect to default database: %v", err)
+			log.Fatalf("failed to open %s database: %v", driver, err)
 		}
+	})
+}
+
+// Open connects to the database and applies any pending migrations.
+func Open(driver, dsn, dbName string) (*gorm.DB, error) {
+	conn, err := Connect(driver, dsn, dbName)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := MigrateUp(conn)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
+	return conn, nil
+}
 
+// Connect connects to the database without touching the schema. For
+// Postgres, dbName is created first when it does not exist. For SQLite, dsn
+// is the database file path, or ":memory:".
+func Connect(driver, dsn, dbName string) (*gorm.DB, error) {
+	switch driver {
+	case "postgres":
+		return openPostgres(dsn, dbName)
+	case "sqlite":
+		return openSQLite(dsn)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
+}
+
+func openPostgres(dsn, dbName string) (*gorm.DB, error) {
+	// First connect to the server's default database to create our target database
+	defaultDB, err := gorm.Open(postgres.Open(maintenanceDSN(dsn)), &gorm.Config{})
+	if err != nil {
+		log.Printf("failed to connect to default database: %v", err)
+	} else {
 		var exists int64
 		err = defaultDB.Raw("SELECT 1 FROM pg_database WHERE datname = ?", dbName).Scan(&exists).Error
 		if err != nil {
-			log.Fatalf("failed to check if database exists: %v", err)
-		}
-		if exists == 0 {
+			log.Printf("failed to check if database exists: %v", err)
+		} else if exists == 0 {
 			log.Printf("Database '%s' does not exist. Creating...\n", dbName)
 			if err := defaultDB.Exec("CREATE DATABASE " + dbName).Error; err != nil {
-				log.Fatalf("failed to create database: %v", err)
+				return nil, fmt.Errorf("failed to create database: %w", err)
 			}
 		} else {
 			log.Printf("Database '%s' already exists. Skipping creation.\n", dbName)
 		}
+		if sqlDB, err := defaultDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+
+	// Now connect to our target database
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+var dbnameParam = regexp.MustCompile(`dbname=\S+`)
+
+// maintenanceDSN points dsn, in URL or key=value form, at the server's
+// "postgres" database.
+func maintenanceDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		u.Path = "/postgres"
+		return u.String()
+	}
+	if dbnameParam.MatchString(dsn) {
+		return dbnameParam.ReplaceAllString(dsn, "dbname=postgres")
+	}
+	return dsn + " dbname=postgres"
+}
 
-		// Now connect to our target database
-		DB, err = gorm.Open(postgres.Open(dburl), &gorm.Config{})
+func openSQLite(path string) (*gorm.DB, error) {
+	conn, err := gorm.Open(sqlite.Open(path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, err
+	}
+	// One connection runs transactions one at a time, so bookings cannot
+	// interleave, and keeps a ":memory:" database alive
+	sqlDB.SetMaxOpenConns(1)
+
+	// SQLite compares times as text, which only orders correctly when every
+	// stored time has the same offset
+	create, update := conn.Callback().Create(), conn.Callback().Update()
+	for _, err := range []error{
+		create.Before("gorm:create").Register("smart_scheduler:utc", storeUTC),
+		create.After("gorm:create").Register(restoreKey, restoreZone),
+		update.Before("gorm:update").Register("smart_scheduler:utc", storeUTC),
+		update.After("gorm:update").Register(restoreKey, restoreZone),
+	} {
 		if err != nil {
-			log.Fatalf("failed to connect to target database: %v", err)
+			return nil, err
 		}
+	}
+	return conn, nil
+}
 
-		// Auto-migrate tables
-		DB.AutoMigrate(&model.User{}, &model.Event{})
-	})
+const restoreKey = "smart_scheduler:restore_zone"
+
+// storeUTC converts the time fields of the records being written to UTC.
+// restoreZone puts the original values back once the write is done, so
+// callers keep seeing times in the zone they used.
+func storeUTC(tx *gorm.DB) {
+	if tx.Statement.Schema == nil {
+		return
+	}
+	var restore []func()
+	value := tx.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			restore = append(restore, recordUTC(tx, reflect.Indirect(value.Index(i)))...)
+		}
+	case reflect.Struct:
+		restore = recordUTC(tx, value)
+	}
+	tx.Statement.Settings.Store(restoreKey, restore)
+}
+
+func restoreZone(tx *gorm.DB) {
+	if restore, ok := tx.Statement.Settings.LoadAndDelete(restoreKey); ok {
+		for _, fn := range restore.([]func()) {
+			fn()
+		}
+	}
+}
+
+func recordUTC(tx *gorm.DB, record reflect.Value) []func() {
+	ctx := tx.Statement.Context
+	var restore []func()
+	for _, field := range tx.Statement.Schema.Fields {
+		value, zero := field.ValueOf(ctx, record)
+		if zero {
+			continue
+		}
+		var utc interface{}
+		switch t := value.(type) {
+		case time.Time:
+			utc = t.UTC()
+		case *time.Time:
+			u := t.UTC()
+			utc = &u
+		default:
+			continue
+		}
+		field, original := field, value
+		field.Set(ctx, record, utc)
+		restore = append(restore, func() { field.Set(ctx, record, original) })
+	}
+	return restore
 }
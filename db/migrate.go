@@ -0,0 +1,272 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Each dialect has its own directory of NNNN_name.up.sql and
+// NNNN_name.down.sql files, with the same versions in both.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change and the statements that undo it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a known migration and when it was applied, nil when it
+// is still pending.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrations returns the embedded migrations for driver, "postgres" or
+// "sqlite", in version order.
+func Migrations(driver string) ([]Migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, title, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_name.%s.sql", name, direction)
+		}
+		content, err := migrationFiles.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		} else if m.Name != title {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, m.Name, title)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration, each in its own transaction,
+// and returns the ones it applied.
+func MigrateUp(conn *gorm.DB) ([]Migration, error) {
+	migrations, err := Migrations(conn.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(conn); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		done := false
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			isApplied, err := lockAndCheck(tx, m.Version)
+			if err != nil || isApplied {
+				return err
+			}
+			if err := execStatements(tx, m.Up); err != nil {
+				return err
+			}
+			done = true
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		if done {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts the latest steps applied migrations, newest first,
+// and returns the ones it reverted.
+func MigrateDown(conn *gorm.DB, steps int) ([]Migration, error) {
+	migrations, err := Migrations(conn.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(conn); err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := migrations[i]
+		done := false
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			isApplied, err := lockAndCheck(tx, m.Version)
+			if err != nil || !isApplied {
+				return err
+			}
+			if err := execStatements(tx, m.Down); err != nil {
+				return err
+			}
+			done = true
+			return tx.Delete(&schemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("reverting migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		if done {
+			reverted = append(reverted, m)
+		}
+	}
+	return reverted, nil
+}
+
+// Status lists every known migration with the time it was applied.
+func Status(conn *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations(conn.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(conn); err != nil {
+		return nil, err
+	}
+	var rows []schemaMigration
+	if err := conn.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := map[int]time.Time{}
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if at, ok := applied[m.Version]; ok {
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+func ensureMigrationsTable(conn *gorm.DB) error {
+	return conn.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version bigint PRIMARY KEY, name text NOT NULL, applied_at timestamp NOT NULL)").Error
+}
+
+// lockAndCheck reports whether version is applied. On Postgres it first
+// takes a transaction-scoped advisory lock, so servers starting together
+// apply each migration once.
+func lockAndCheck(tx *gorm.DB, version int) (bool, error) {
+	if tx.Dialector.Name() == "postgres" {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('schema_migrations'))").Error; err != nil {
+			return false, err
+		}
+	}
+	var count int64
+	err := tx.Model(&schemaMigration{}).Where("version = ?", version).Count(&count).Error
+	return count > 0, err
+}
+
+// execStatements runs the ;-separated statements of a migration file one at
+// a time, since not every driver accepts several in one call.
+func execStatements(tx *gorm.DB, sql string) error {
+	var lines []string
+	for _, line := range strings.Split(sql, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";") {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		if tx.Dialector.Name() == "sqlite" {
+			var skip bool
+			var err error
+			statement, skip, err = sqliteAddColumn(tx, statement)
+			if err != nil {
+				return err
+			}
+			if skip {
+				continue
+			}
+		}
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var addColumnIfNotExists = regexp.MustCompile(`(?i)^ALTER TABLE (\w+) ADD COLUMN IF NOT EXISTS (\w+)`)
+
+// sqliteAddColumn rewrites ALTER TABLE ... ADD COLUMN IF NOT EXISTS, which
+// SQLite lacks, into a plain ADD COLUMN, and reports whether to skip it
+// because the table already has the column.
+func sqliteAddColumn(tx *gorm.DB, statement string) (string, bool, error) {
+	match := addColumnIfNotExists.FindStringSubmatch(statement)
+	if match == nil {
+		return statement, false, nil
+	}
+	var count int64
+	err := tx.Raw("SELECT count(*) FROM pragma_table_info(?) WHERE name = ?", match[1], match[2]).Scan(&count).Error
+	if err != nil {
+		return "", false, err
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", match[1], statement[len(match[0])-len(match[2]):]), count > 0, nil
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"smart-scheduler/model"
+	"testing"
+	"time"
+)
+
+func TestMigrationsPairUpAcrossDialects(t *testing.T) {
+	postgres, err := Migrations("postgres")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sqlite, err := Migrations("sqlite")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(postgres) == 0 || len(postgres) != len(sqlite) {
+		t.Fatalf("Expected the same migrations for both dialects, got %d and %d", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Errorf("Migration %d differs: %04d_%s and %04d_%s", i,
+				postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+	if _, err := Migrations("mysql"); err == nil {
+		t.Error("Expected an error for a dialect without migrations")
+	}
+}
+
+func TestMigrateUpDownAndStatus(t *testing.T) {
+	conn, err := Connect("sqlite", ":memory:", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	migrations, _ := Migrations("sqlite")
+
+	status, err := Status(conn)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, s := range status {
+		if s.AppliedAt != nil {
+			t.Errorf("Expected %04d_%s to be pending", s.Version, s.Name)
+		}
+	}
+
+	applied, err := MigrateUp(conn)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("Expected %d migrations applied, got %d", len(migrations), len(applied))
+	}
+	if again, err := MigrateUp(conn); err != nil || len(again) != 0 {
+		t.Errorf("Expected a second run to apply nothing, got %d, %v", len(again), err)
+	}
+
+	// Every model field has a column, so the SQL keeps up with the structs
+	migrator := conn.Migrator()
+	for _, value := range []interface{}{&model.User{}, &model.Event{}, &model.Hold{}, &model.WorkingHours{}, &model.Meeting{}, &model.MeetingAttendee{}, &model.Resource{}, &model.MeetingResource{}} {
+		stmt := conn.Model(value).Statement
+		if err := stmt.Parse(value); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !migrator.HasColumn(value, field.DBName) {
+				t.Errorf("Table %s has no column %s", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+	if !migrator.HasIndex(&model.Event{}, "idx_events_user_time") {
+		t.Error("Expected the events overlap index")
+	}
+
+	// Back to the initial schema, newest first
+	reverted, err := MigrateDown(conn, len(migrations)-1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(reverted) != len(migrations)-1 || reverted[0].Version != migrations[len(migrations)-1].Version {
+		t.Fatalf("Expected every migration but the first reverted newest first, got %+v", reverted)
+	}
+	if migrator.HasIndex(&model.Event{}, "idx_events_user_time") {
+		t.Error("Expected the overlap index to be dropped")
+	}
+	status, _ = Status(conn)
+	if status[0].AppliedAt == nil || status[1].AppliedAt != nil {
+		t.Errorf("Expected only %04d_%s to stay applied, got %+v", status[0].Version, status[0].Name, status)
+	}
+
+	if _, err := MigrateDown(conn, len(migrations)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if migrator.HasTable(&model.User{}) {
+		t.Error("Expected every table to be dropped")
+	}
+	if applied, err := MigrateUp(conn); err != nil || len(applied) != len(migrations) {
+		t.Errorf("Expected every migration to apply again, got %d, %v", len(applied), err)
+	}
+}
+
+// The users and events tables as the baseline's AutoMigrate created them
+type baselineUser struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement"`
+	UserCode string `gorm:"unique;not null"`
+	Name     string
+	Events   []baselineEvent `gorm:"foreignKey:UserID;references:UserCode"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineEvent struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	EventCode string `gorm:"unique;not null"`
+	UserID    string
+	Title     string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+func (baselineEvent) TableName() string { return "events" }
+
+func TestMigrateUpAdoptsBaselineSchema(t *testing.T) {
+	conn, err := Connect("sqlite", ":memory:", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := conn.AutoMigrate(&baselineUser{}, &baselineEvent{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	if err := conn.Create(&baselineUser{UserCode: "user1", Name: "Alice"}).Error; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := conn.Create(&baselineEvent{EventCode: "event1", UserID: "user1", Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)}).Error; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	migrations, _ := Migrations("sqlite")
+	applied, err := MigrateUp(conn)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("Expected %d migrations applied, got %d", len(migrations), len(applied))
+	}
+
+	migrator := conn.Migrator()
+	for _, value := range []interface{}{&model.User{}, &model.Event{}} {
+		stmt := conn.Model(value).Statement
+		if err := stmt.Parse(value); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !migrator.HasColumn(value, field.DBName) {
+				t.Errorf("Table %s has no column %s", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+
+	var event model.Event
+	if err := conn.Where("event_code = ?", "event1").First(&event).Error; err != nil {
+		t.Fatalf("Expected the baseline event to survive, got %v", err)
+	}
+	if event.Title != "Standup" || event.RRule != "" {
+		t.Errorf("Unexpected event after migrating: %+v", event)
+	}
+}
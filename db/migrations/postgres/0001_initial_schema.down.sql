@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS meeting_attendees;
+DROP TABLE IF EXISTS meetings;
+DROP TABLE IF EXISTS working_hours;
+DROP TABLE IF EXISTS holds;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS users;
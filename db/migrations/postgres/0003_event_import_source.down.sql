@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_events_source_uid;
+ALTER TABLE events DROP COLUMN IF EXISTS source;
+ALTER TABLE events DROP COLUMN IF EXISTS uid;
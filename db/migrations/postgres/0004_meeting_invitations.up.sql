@@ -0,0 +1,4 @@
+-- Invitations are emailed to attendees, and iTIP updates need a sequence
+-- number that grows every time the meeting changes.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email text;
+ALTER TABLE meetings ADD COLUMN IF NOT EXISTS sequence bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Manually placed events can be marked as free time, shown on the calendar
+-- without blocking scheduling.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS transparent boolean NOT NULL DEFAULT false;
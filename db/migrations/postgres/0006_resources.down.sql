@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS meeting_resources;
+DROP TABLE IF EXISTS resources;
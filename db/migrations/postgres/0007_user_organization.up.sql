@@ -0,0 +1,3 @@
+-- Users belong to an organization, whose scoring defaults apply to the
+-- meetings they organize.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS organization text;
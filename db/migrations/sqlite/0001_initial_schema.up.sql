@@ -0,0 +1,85 @@
+-- The schema gorm's AutoMigrate used to create. IF NOT EXISTS lets databases
+-- created that way adopt the migrations, and the ALTER TABLE steps below add
+-- the columns their users and events tables are missing.
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_code text NOT NULL,
+    name text,
+    timezone text,
+    CONSTRAINT uni_users_user_code UNIQUE (user_code)
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    event_code text NOT NULL,
+    user_id text,
+    title text,
+    start_time datetime,
+    end_time datetime,
+    rrule text,
+    ex_dates text,
+    series_end datetime,
+    recurring_event_id integer,
+    recurrence_id datetime,
+    CONSTRAINT uni_events_event_code UNIQUE (event_code),
+    CONSTRAINT fk_users_events FOREIGN KEY (user_id) REFERENCES users (user_code)
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone text;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS rrule text;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS ex_dates text;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS series_end datetime;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS recurring_event_id integer;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS recurrence_id datetime;
+CREATE INDEX IF NOT EXISTS idx_events_recurring_event_id ON events (recurring_event_id);
+
+CREATE TABLE IF NOT EXISTS holds (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    hold_code text NOT NULL,
+    user_id text,
+    optional numeric,
+    title text,
+    start_time datetime,
+    end_time datetime,
+    expires_at datetime,
+    organizer_id text,
+    description text,
+    location text
+);
+CREATE INDEX IF NOT EXISTS idx_holds_hold_code ON holds (hold_code);
+CREATE INDEX IF NOT EXISTS idx_holds_user_id ON holds (user_id);
+CREATE INDEX IF NOT EXISTS idx_holds_expires_at ON holds (expires_at);
+
+CREATE TABLE IF NOT EXISTS working_hours (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id text NOT NULL,
+    weekday integer,
+    start_minute integer,
+    end_minute integer,
+    CONSTRAINT fk_users_working_hours FOREIGN KEY (user_id) REFERENCES users (user_code)
+);
+CREATE INDEX IF NOT EXISTS idx_working_hours_user_id ON working_hours (user_id);
+
+CREATE TABLE IF NOT EXISTS meetings (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    meeting_code text NOT NULL,
+    organizer_id text,
+    title text,
+    description text,
+    location text,
+    start_time datetime,
+    end_time datetime,
+    rrule text,
+    series_end datetime,
+    CONSTRAINT uni_meetings_meeting_code UNIQUE (meeting_code)
+);
+
+CREATE TABLE IF NOT EXISTS meeting_attendees (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    meeting_id integer NOT NULL,
+    user_id text NOT NULL,
+    optional numeric,
+    status text,
+    CONSTRAINT fk_meetings_attendees FOREIGN KEY (meeting_id) REFERENCES meetings (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_meeting_attendees_meeting_id ON meeting_attendees (meeting_id);
+CREATE INDEX IF NOT EXISTS idx_meeting_attendees_user_id ON meeting_attendees (user_id);
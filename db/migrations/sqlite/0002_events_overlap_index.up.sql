@@ -0,0 +1,2 @@
+-- Serves the per-user overlap queries: user_id = ? AND start_time < ? AND end_time > ?
+CREATE INDEX IF NOT EXISTS idx_events_user_time ON events (user_id, start_time, end_time);
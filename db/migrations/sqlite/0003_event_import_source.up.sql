@@ -0,0 +1,5 @@
+-- Events imported from other calendars keep their iCalendar UID and the
+-- name of the feed they came from, so that re-imports update them in place.
+ALTER TABLE events ADD COLUMN uid text;
+ALTER TABLE events ADD COLUMN source text;
+CREATE INDEX IF NOT EXISTS idx_events_source_uid ON events (user_id, source, uid);
@@ -0,0 +1,2 @@
+ALTER TABLE meetings DROP COLUMN sequence;
+ALTER TABLE users DROP COLUMN email;
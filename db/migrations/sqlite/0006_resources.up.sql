@@ -0,0 +1,21 @@
+-- Rooms and equipment with their own calendar, made of the meetings that
+-- book them.
+CREATE TABLE IF NOT EXISTS resources (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    resource_code text NOT NULL,
+    name text,
+    type text,
+    capacity integer,
+    location text,
+    features text,
+    CONSTRAINT uni_resources_resource_code UNIQUE (resource_code)
+);
+
+CREATE TABLE IF NOT EXISTS meeting_resources (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    meeting_id integer NOT NULL,
+    resource_id text NOT NULL,
+    CONSTRAINT fk_meetings_resources FOREIGN KEY (meeting_id) REFERENCES meetings (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_meeting_resources_meeting_id ON meeting_resources (meeting_id);
+CREATE INDEX IF NOT EXISTS idx_meeting_resources_resource_id ON meeting_resources (resource_id);
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"smart-scheduler/api"
+	"smart-scheduler/repository"
+	service "smart-scheduler/service"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func UpdateEvent(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	eventId, err := strconv.ParseUint(ps.ByName("eventID"), 10, 64)
+	if err != nil {
+		api.Error(w, r, errors.New("invalid event id"), http.StatusBadRequest)
+		return
+	}
+	var req repository.EventUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	event, err := service.UpdateEvent(uint(eventId), req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, event)
+}
+
+func DeleteEvent(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	eventId, err := strconv.ParseUint(ps.ByName("eventID"), 10, 64)
+	if err != nil {
+		api.Error(w, r, errors.New("invalid event id"), http.StatusBadRequest)
+		return
+	}
+	scope := r.URL.Query().Get("scope")
+	occurrence := r.URL.Query().Get("occurrence")
+	if err := service.DeleteEvent(uint(eventId), scope, occurrence); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateUserEvent places a personal block, such as focus time or travel, on
+// the user's calendar.
+func CreateUserEvent(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req repository.EventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	event, err := service.CreateUserEvent(ps.ByName("userID"), req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.Created(w, r, "/api/v1/users/"+event.UserID+"/events/"+strconv.FormatUint(uint64(event.ID), 10), event)
+}
+
+func UpdateUserEvent(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	eventId, err := strconv.ParseUint(ps.ByName("eventID"), 10, 64)
+	if err != nil {
+		api.Error(w, r, errors.New("invalid event id"), http.StatusBadRequest)
+		return
+	}
+	var req repository.UserEventUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	event, err := service.UpdateUserEvent(ps.ByName("userID"), uint(eventId), req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, event)
+}
+
+func DeleteUserEvent(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	eventId, err := strconv.ParseUint(ps.ByName("eventID"), 10, 64)
+	if err != nil {
+		api.Error(w, r, errors.New("invalid event id"), http.StatusBadRequest)
+		return
+	}
+	if err := service.DeleteUserEvent(ps.ByName("userID"), uint(eventId)); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxImportBytes bounds the size of an uploaded .ics file.
+const maxImportBytes = 10 << 20
+
+// ImportCalendar reads a .ics body into the user's calendar as busy time.
+// The source query parameter names the feed so re-imports update in place.
+func ImportCalendar(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	result, err := service.ImportCalendar(ps.ByName("userID"), r.URL.Query().Get("source"), body)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, result)
+}
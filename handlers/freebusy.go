@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"smart-scheduler/api"
+	"smart-scheduler/repository"
+	service "smart-scheduler/service"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func GetFreeBusy(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req repository.FreeBusyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	resp, err := service.FreeBusy(req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, resp)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+	"smart-scheduler/api"
+	service "smart-scheduler/service"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func ConfirmHold(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	resp, err := service.ConfirmHold(ps.ByName("holdID"))
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.Created(w, r, "/api/v1/meetings/"+resp.MeetingID, resp)
+}
+
+func ReleaseHold(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := service.ReleaseHold(ps.ByName("holdID")); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"smart-scheduler/api"
+	"smart-scheduler/repository"
+	service "smart-scheduler/service"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func GetMeeting(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	meeting, err := service.GetMeeting(ps.ByName("meetingID"))
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, meeting)
+}
+
+func CancelMeeting(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if _, err := service.CancelMeeting(ps.ByName("meetingID")); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func RescheduleMeeting(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req repository.RescheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	resp, err := service.RescheduleMeeting(ps.ByName("meetingID"), req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, resp)
+}
+
+// RespondToMeeting records an attendee's accept, decline or tentative.
+func RespondToMeeting(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req repository.RSVPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	meeting, err := service.RespondToMeeting(ps.ByName("meetingID"), req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, meeting)
+}
+
+// maxReplyBytes bounds the size of an iTIP REPLY body.
+const maxReplyBytes = 1 << 20
+
+// ProcessReply records the responses in an iTIP REPLY sent back by an
+// attendee's calendar client.
+func ProcessReply(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	updates, err := service.ProcessReply(http.MaxBytesReader(w, r.Body, maxReplyBytes))
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, updates)
+}
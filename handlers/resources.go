@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"smart-scheduler/api"
+	"smart-scheduler/repository"
+	service "smart-scheduler/service"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func CreateResource(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req repository.ResourceDetails
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	resource, err := service.CreateResource(req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.Created(w, r, "/api/v1/resources/"+resource.ResourceCode, resource)
+}
+
+// ListResources returns every resource, or those of the "type" given.
+func ListResources(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	resources, err := service.ListResources(r.URL.Query().Get("type"))
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, resources)
+}
+
+func GetResource(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	resource, err := service.GetResource(ps.ByName("resourceID"))
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, resource)
+}
+
+func DeleteResource(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := service.DeleteResource(ps.ByName("resourceID")); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetResourceCalendar lists the meetings that booked the resource between
+// the required start and end query parameters.
+func GetResourceCalendar(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	query := r.URL.Query()
+	events, err := service.GetResourceCalendar(ps.ByName("resourceID"), query.Get("start"), query.Get("end"))
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, events)
+}
@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
 	"smart-scheduler/api"
+	"smart-scheduler/apperr"
 	"smart-scheduler/repository"
 	service "smart-scheduler/service"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -13,29 +18,93 @@ import (
 func ScheduleMeeting(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	var req repository.ScheduleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		api.Error(w, r, err, http.StatusBadRequest)
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
 		return
 	}
 	resp, err := service.ScheduleEvent(req)
 	if err != nil {
-		api.Error(w, r, err, http.StatusConflict)
+		api.Error(w, r, err, 0)
+		return
+	}
+	// Holds are only reachable through their hold ID, not as a meeting
+	location := ""
+	if resp.HoldID == "" {
+		location = "/api/v1/meetings/" + resp.MeetingID
+	}
+	api.Created(w, r, location, resp)
+}
+
+func ProposeMeetingSlots(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req repository.ProposalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	resp, err := service.ProposeSlots(req)
+	if err != nil {
+		api.Error(w, r, err, 0)
 		return
 	}
-	w.WriteHeader(http.StatusCreated)
 	api.SuccessJson(w, r, resp)
 }
 
 func GetUserCalendar(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	// Extract userID from httprouter params
 	userId := ps.ByName("userID")
+	if strings.HasSuffix(userId, ".ics") || wantsCalendar(r) {
+		exportCalendar(w, r, strings.TrimSuffix(userId, ".ics"))
+		return
+	}
 	start := r.URL.Query().Get("start")
 	end := r.URL.Query().Get("end")
 
 	events, err := service.GetCalendarEvents(userId, start, end)
 	if err != nil {
-		api.Error(w, r, err, http.StatusInternalServerError)
+		api.Error(w, r, err, 0)
 		return
 	}
 
 	api.SuccessJson(w, r, events)
 }
+
+// decodeError explains why a request body could not be decoded. A field
+// of the wrong JSON type is reported as a field error.
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return &apperr.ValidationError{Malformed: true, Fields: []apperr.FieldError{{
+			Path:    typeErr.Field,
+			Code:    apperr.CodeInvalidFormat,
+			Message: "cannot be a JSON " + typeErr.Value,
+		}}}
+	}
+	return err
+}
+
+// wantsCalendar reports whether the Accept header asks for iCalendar data.
+func wantsCalendar(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accepted); err == nil && mediaType == "text/calendar" {
+			return true
+		}
+	}
+	return false
+}
+
+// exportCalendar writes userId's calendar as an RFC 5545 VCALENDAR that
+// desktop calendar clients can subscribe to.
+func exportCalendar(w http.ResponseWriter, r *http.Request, userId string) {
+	cal, err := service.ExportCalendar(userId)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	var body bytes.Buffer
+	if err := cal.Encode(&body); err != nil {
+		api.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="`+userId+`.ics"`)
+	api.Success(w, r, body.Bytes())
+}
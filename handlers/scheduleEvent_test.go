@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"smart-scheduler/api"
 	"smart-scheduler/repository"
 	"testing"
 	"time"
@@ -148,7 +149,7 @@ func TestGetUserCalendar(t *testing.T) {
 				"start": "2025-08-09T19:00:00+05:30",
 				"end":   "2025-08-09T10:00:00+05:30",
 			},
-			expectedStatus: 0, // Should return error but will fail due to nil DB first
+			expectedStatus: http.StatusUnprocessableEntity, // checked before the store is read
 			expectError:    true,
 		},
 		{
@@ -158,7 +159,7 @@ func TestGetUserCalendar(t *testing.T) {
 				"start": "invalid-time",
 				"end":   "2025-08-09T18:00:00+05:30",
 			},
-			expectedStatus: 0, // Should return error but will fail due to nil DB first
+			expectedStatus: http.StatusUnprocessableEntity, // checked before the store is read
 			expectError:    true,
 		},
 	}
@@ -196,6 +197,9 @@ func TestGetUserCalendar(t *testing.T) {
 
 			// For this test, we're mainly checking that the handler doesn't panic
 			// and processes the request structure correctly
+			if tt.expectedStatus != 0 && w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
 			t.Logf("Response status: %d", w.Code)
 			t.Logf("Response body: %s", w.Body.String())
 		})
@@ -267,3 +271,63 @@ func TestGetUserCalendarParameterExtraction(t *testing.T) {
 		})
 	}
 }
+
+func TestWantsCalendar(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected bool
+	}{
+		{"text/calendar", true},
+		{"application/json, text/calendar; charset=utf-8", true},
+		{"application/json", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/calendar/user1", nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := wantsCalendar(req); got != tt.expected {
+			t.Errorf("Accept %q: expected %v, got %v", tt.accept, tt.expected, got)
+		}
+	}
+}
+
+func TestScheduleMeetingFieldErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedPath   string
+	}{
+		{
+			name:           "Wrong JSON type",
+			body:           `{"userIDs": ["user1"], "durationMinutes": "sixty"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedPath:   "durationMinutes",
+		},
+		{
+			name:           "Missing participants",
+			body:           `{"durationMinutes": 60, "timeRange": {"start": "2025-08-09T09:00:00+05:30", "end": "2025-08-09T17:00:00+05:30"}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedPath:   "userIDs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/schedule", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			ScheduleMeeting(w, req, httprouter.Params{})
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var response api.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Response is not valid JSON: %v", err)
+			}
+			if len(response.Errors) == 0 || response.Errors[0].Path != tt.expectedPath {
+				t.Errorf("Expected a field error for %s, got %+v", tt.expectedPath, response.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"smart-scheduler/api"
+	"smart-scheduler/apperr"
+	"smart-scheduler/repository"
+	service "smart-scheduler/service"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func CreateUser(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req repository.UserDetails
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	user, err := service.CreateUser(req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.Created(w, r, "/api/v1/users/"+user.UserCode, user)
+}
+
+// ListUsers returns a page of users. "q" searches names, "page" counts from
+// 1 and "pageSize" defaults to 20.
+func ListUsers(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	query := r.URL.Query()
+	page, err := intParam(query.Get("page"), 1)
+	if err != nil {
+		api.Error(w, r, apperr.Invalid("page", apperr.CodeInvalidFormat, "must be a whole number"), 0)
+		return
+	}
+	pageSize, err := intParam(query.Get("pageSize"), 0)
+	if err != nil {
+		api.Error(w, r, apperr.Invalid("pageSize", apperr.CodeInvalidFormat, "must be a whole number"), 0)
+		return
+	}
+	list, err := service.ListUsers(query.Get("q"), page, pageSize)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, list)
+}
+
+func GetUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	user, err := service.GetUser(ps.ByName("userID"))
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, user)
+}
+
+func UpdateUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req repository.UserUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	user, err := service.UpdateUser(ps.ByName("userID"), req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, user)
+}
+
+func DeleteUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := service.DeleteUser(ps.ByName("userID")); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetUserProfile(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	profile, err := service.GetUserProfile(ps.ByName("userID"))
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, profile)
+}
+
+func UpdateUserProfile(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req repository.UserProfile
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.Error(w, r, decodeError(err), http.StatusBadRequest)
+		return
+	}
+	profile, err := service.UpdateUserProfile(ps.ByName("userID"), req)
+	if err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	api.SuccessJson(w, r, profile)
+}
+
+// intParam parses an optional integer query parameter.
+func intParam(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,274 @@
+// Package ical reads and writes RFC 5545 iCalendar data.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ProdID identifies the scheduler in generated calendars.
+const ProdID = "-//Smart Scheduler//Smart Scheduler//EN"
+
+const (
+	localLayout = "20060102T150405"
+	utcLayout   = "20060102T150405Z"
+)
+
+// Calendar is a VCALENDAR object. Times are written in TimeZone, which gets
+// a VTIMEZONE component, or in UTC when TimeZone is nil.
+type Calendar struct {
+	Name     string // X-WR-CALNAME shown by subscribing clients
+	Method   string // iTIP method, empty for a plain calendar
+	TimeZone *time.Location
+	Events   []Event
+}
+
+// Event is a VEVENT. A series carries RRule and ExDates; an overridden
+// occurrence shares the series UID and sets RecurrenceID.
+type Event struct {
+	UID          string
+	Sequence     int
+	Stamp        time.Time // DTSTAMP
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Status       string // "CONFIRMED", "TENTATIVE" or "CANCELLED"
+	Transparent  bool   // TRANSP:TRANSPARENT, the event does not block time
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time
+	Organizer    *Attendee
+	Attendees    []Attendee
+}
+
+// Attendee is an ORGANIZER or ATTENDEE, addressed by email.
+type Attendee struct {
+	Email    string
+	Name     string // CN
+	Role     string // "REQ-PARTICIPANT", "OPT-PARTICIPANT" or "CHAIR"
+	PartStat string // "NEEDS-ACTION", "ACCEPTED", "DECLINED" or "TENTATIVE"
+	RSVP     bool   // a reply is expected
+}
+
+// Encode writes the calendar with CRLF line endings and lines folded at 75
+// octets.
+func (c *Calendar) Encode(w io.Writer) error {
+	lw := &lineWriter{w: w}
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + ProdID)
+	lw.line("CALSCALE:GREGORIAN")
+	if c.Method != "" {
+		lw.line("METHOD:" + c.Method)
+	}
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+	if c.TimeZone != nil {
+		lw.line("X-WR-TIMEZONE:" + c.TimeZone.String())
+		from, to := c.span()
+		writeTimeZone(lw, c.TimeZone, from, to)
+	}
+	for _, e := range c.Events {
+		c.writeEvent(lw, e)
+	}
+	lw.line("END:VCALENDAR")
+	return lw.err
+}
+
+func (c *Calendar) writeEvent(lw *lineWriter, e Event) {
+	lw.line("BEGIN:VEVENT")
+	lw.line("UID:" + e.UID)
+	lw.line("DTSTAMP:" + e.Stamp.UTC().Format(utcLayout))
+	lw.line(c.dateTime("DTSTART", e.Start))
+	lw.line(c.dateTime("DTEND", e.End))
+	if e.RecurrenceID != nil {
+		lw.line(c.dateTime("RECURRENCE-ID", *e.RecurrenceID))
+	}
+	if e.RRule != "" {
+		lw.line("RRULE:" + e.RRule)
+	}
+	for _, d := range e.ExDates {
+		lw.line(c.dateTime("EXDATE", d))
+	}
+	if e.Sequence > 0 {
+		lw.line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+	}
+	lw.line("SUMMARY:" + escapeText(e.Summary))
+	if e.Description != "" {
+		lw.line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	if e.Location != "" {
+		lw.line("LOCATION:" + escapeText(e.Location))
+	}
+	if e.Status != "" {
+		lw.line("STATUS:" + e.Status)
+	}
+	if e.Transparent {
+		lw.line("TRANSP:TRANSPARENT")
+	}
+	if e.Organizer != nil {
+		lw.line(address("ORGANIZER", *e.Organizer))
+	}
+	for _, a := range e.Attendees {
+		lw.line(address("ATTENDEE", a))
+	}
+	lw.line("END:VEVENT")
+}
+
+// address renders an ORGANIZER or ATTENDEE property with its parameters.
+func address(name string, a Attendee) string {
+	line := name
+	if a.Name != "" {
+		line += ";CN=" + paramValue(a.Name)
+	}
+	if a.Role != "" {
+		line += ";ROLE=" + a.Role
+	}
+	if a.PartStat != "" {
+		line += ";PARTSTAT=" + a.PartStat
+	}
+	if a.RSVP {
+		line += ";RSVP=TRUE"
+	}
+	return line + ":mailto:" + a.Email
+}
+
+// paramValue quotes a parameter value when it contains separators. Double
+// quotes cannot be escaped inside one, so they are dropped, and control
+// characters such as line breaks, which would end the line, become spaces.
+func paramValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '"':
+			return -1
+		case unicode.IsControl(r):
+			return ' '
+		}
+		return r
+	}, s)
+	if strings.ContainsAny(s, ";:,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// dateTime renders a DATE-TIME property in the calendar's zone.
+func (c *Calendar) dateTime(name string, t time.Time) string {
+	if c.TimeZone == nil {
+		return name + ":" + t.UTC().Format(utcLayout)
+	}
+	return name + ";TZID=" + c.TimeZone.String() + ":" + t.In(c.TimeZone).Format(localLayout)
+}
+
+// span returns the period the VTIMEZONE must describe: from the first event
+// to a few years past the later of the last event and now, so that open
+// series keep correct offsets for a while.
+func (c *Calendar) span() (time.Time, time.Time) {
+	now := time.Now()
+	from, to := now, now
+	for _, e := range c.Events {
+		if e.Start.Before(from) {
+			from = e.Start
+		}
+		if e.End.After(to) {
+			to = e.End
+		}
+	}
+	return from, to.AddDate(5, 0, 0)
+}
+
+// writeTimeZone describes loc between from and to as a VTIMEZONE with one
+// observance per offset change, taken from Go's zone data.
+func writeTimeZone(lw *lineWriter, loc *time.Location, from, to time.Time) {
+	lw.line("BEGIN:VTIMEZONE")
+	lw.line("TZID:" + loc.String())
+
+	t := from.In(loc)
+	name, offset := t.Zone()
+	start, end := t.ZoneBounds()
+	if start.IsZero() {
+		start = time.Date(1970, 1, 1, 0, 0, 0, 0, loc)
+	}
+	writeObservance(lw, t.IsDST(), name, start.In(time.FixedZone("", offset)), offset, offset)
+	for !end.IsZero() && end.Before(to) {
+		t = end.In(loc)
+		newName, newOffset := t.Zone()
+		// An observance starts at the local time just before the change
+		writeObservance(lw, t.IsDST(), newName, end.In(time.FixedZone("", offset)), offset, newOffset)
+		offset = newOffset
+		_, end = t.ZoneBounds()
+	}
+	lw.line("END:VTIMEZONE")
+}
+
+func writeObservance(lw *lineWriter, dst bool, name string, start time.Time, from, to int) {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+	lw.line("BEGIN:" + kind)
+	lw.line("DTSTART:" + start.Format(localLayout))
+	lw.line("TZOFFSETFROM:" + formatOffset(from))
+	lw.line("TZOFFSETTO:" + formatOffset(to))
+	if name != "" {
+		lw.line("TZNAME:" + escapeText(name))
+	}
+	lw.line("END:" + kind)
+}
+
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// lineWriter writes content lines, folding them and keeping the first error.
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = io.WriteString(lw.w, fold(s)+"\r\n")
+}
+
+// fold splits s into lines of at most 75 octets, continuing each with a
+// space, without breaking UTF-8 sequences.
+func fold(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	width := limit
+	for len(s) > width {
+		cut := width
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		width = limit - 1 // the leading space counts
+	}
+	b.WriteString(s)
+	return b.String()
+}
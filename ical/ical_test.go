@@ -0,0 +1,129 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+	start := time.Date(2025, 8, 11, 10, 0, 0, 0, ist)
+	moved := start.AddDate(0, 0, 7)
+	cal := &Calendar{
+		Name: "Bob Smith",
+		Events: []Event{
+			{
+				UID:       "series2@smart-scheduler",
+				Stamp:     start,
+				Start:     start,
+				End:       start.Add(30 * time.Minute),
+				Summary:   "Weekly 1:1; notes, and more",
+				RRule:     "FREQ=WEEKLY;COUNT=4",
+				ExDates:   []time.Time{start.AddDate(0, 0, 14)},
+				Organizer: &Attendee{Email: "alice@example.com", Name: "Alice"},
+				Attendees: []Attendee{
+					{Email: "bob@b.io", Name: "Smith, Bob", Role: "OPT-PARTICIPANT", RSVP: true},
+				},
+			},
+			{
+				UID:          "series2@smart-scheduler",
+				Stamp:        start,
+				Start:        moved.Add(5 * time.Hour),
+				End:          moved.Add(5*time.Hour + 30*time.Minute),
+				Summary:      "Weekly 1:1",
+				RecurrenceID: &moved,
+			},
+		},
+	}
+	var out bytes.Buffer
+	if err := cal.Encode(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text := out.String()
+
+	for _, line := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"DTSTART:20250811T043000Z\r\n",
+		"RRULE:FREQ=WEEKLY;COUNT=4\r\n",
+		"EXDATE:20250825T043000Z\r\n",
+		"RECURRENCE-ID:20250818T043000Z\r\n",
+		`SUMMARY:Weekly 1:1\; notes\, and more` + "\r\n",
+		"ORGANIZER;CN=Alice:mailto:alice@example.com\r\n",
+		`ATTENDEE;CN="Smith, Bob";ROLE=OPT-PARTICIPANT;RSVP=TRUE:mailto:bob@b.io` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("Expected %q in\n%s", line, text)
+		}
+	}
+	if strings.Count(text, "BEGIN:VEVENT") != 2 || strings.Contains(text, "VTIMEZONE") {
+		t.Errorf("Expected two events in UTC, got\n%s", text)
+	}
+}
+
+func TestEncodeParamLineBreak(t *testing.T) {
+	start := time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC)
+	cal := &Calendar{Events: []Event{{
+		UID:       "a@b",
+		Stamp:     start,
+		Start:     start,
+		End:       start.Add(time.Hour),
+		Summary:   "Standup",
+		Attendees: []Attendee{{Email: "eve@example.com", Name: "Eve\r\nX:\"evil\""}},
+	}}}
+	var out bytes.Buffer
+	if err := cal.Encode(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text := out.String()
+	if !strings.Contains(text, `ATTENDEE;CN="Eve  X:evil":mailto:eve@example.com`+"\r\n") || strings.Contains(text, "\nX:") {
+		t.Errorf("Expected the line break to stay inside the parameter, got\n%s", text)
+	}
+}
+
+func TestEncodeTimeZone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No zone data: %v", err)
+	}
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, newYork)
+	cal := &Calendar{
+		TimeZone: newYork,
+		Events:   []Event{{UID: "a@b", Stamp: start, Start: start, End: start.Add(time.Hour), Summary: "Standup"}},
+	}
+	var out bytes.Buffer
+	if err := cal.Encode(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text := out.String()
+	for _, line := range []string{
+		"X-WR-TIMEZONE:America/New_York\r\n",
+		"TZID:America/New_York\r\n",
+		"DTSTART;TZID=America/New_York:20250303T090000\r\n",
+		// Clocks went forward at 02:00 EST on 9 March 2025
+		"BEGIN:DAYLIGHT\r\nDTSTART:20250309T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nTZNAME:EDT\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20251102T020000\r\nTZOFFSETFROM:-0400\r\nTZOFFSETTO:-0500\r\n",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("Expected %q in\n%s", line, text)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	long := "DESCRIPTION:" + strings.Repeat("é", 60)
+	folded := fold(long)
+	for _, line := range strings.Split(folded, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line is %d octets: %q", len(line), line)
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != long {
+		t.Errorf("Expected unfolding to restore the line, got %q", unfolded)
+	}
+	if fold("SUMMARY:short") != "SUMMARY:short" {
+		t.Error("Expected short lines to be left alone")
+	}
+}
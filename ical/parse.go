@@ -0,0 +1,419 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Property is one content line: NAME;PARAM=value:VALUE.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN/END block with its properties and nested components.
+type Component struct {
+	Name       string
+	Props      []Property
+	Components []*Component
+}
+
+// Prop returns the first property called name, or nil.
+func (c *Component) Prop(name string) *Property {
+	for i := range c.Props {
+		if c.Props[i].Name == name {
+			return &c.Props[i]
+		}
+	}
+	return nil
+}
+
+// Value returns the value of the first property called name, or "".
+func (c *Component) Value(name string) string {
+	if p := c.Prop(name); p != nil {
+		return p.Value
+	}
+	return ""
+}
+
+// Parse reads iCalendar data into its top-level VCALENDAR component.
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*Component
+	var root *Component
+	for n, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		switch prop.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else if root == nil {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", n+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside a component", n+1)
+			}
+			c := stack[len(stack)-1]
+			c.Props = append(c.Props, prop)
+		}
+	}
+	if root == nil || root.Name != "VCALENDAR" {
+		return nil, errors.New("no VCALENDAR found")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold joins folded lines and drops empty ones.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine splits a content line into name, parameters and value. Colons
+// and semicolons inside quoted parameter values do not count.
+func parseLine(line string) (Property, error) {
+	prop := Property{Params: map[string]string{}}
+	quoted := false
+	start := 0
+	var name string
+	var params []string
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ';' || c == ':':
+			if name == "" {
+				name = line[start:i]
+			} else {
+				params = append(params, line[start:i])
+			}
+			start = i + 1
+			if c == ':' {
+				prop.Name = strings.ToUpper(name)
+				prop.Value = line[i+1:]
+				for _, param := range params {
+					key, value, _ := strings.Cut(param, "=")
+					prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+				}
+				if prop.Name == "" {
+					return prop, fmt.Errorf("invalid content line %q", line)
+				}
+				return prop, nil
+			}
+		}
+	}
+	return prop, fmt.Errorf("invalid content line %q", line)
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// InvalidEvent is a VEVENT that Decode could not read.
+type InvalidEvent struct {
+	UID string
+	Err error
+}
+
+// Decode reads iCalendar data into a Calendar. Floating times and dates
+// are read in loc. A TZID that is not an IANA zone name falls back to the
+// standard offset of the file's matching VTIMEZONE. Events that cannot be
+// read are returned separately instead of failing the whole calendar.
+func Decode(r io.Reader, loc *time.Location) (*Calendar, []InvalidEvent, error) {
+	root, err := Parse(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	d := &decoder{loc: loc, zones: map[string]*time.Location{}, fallback: map[string]*time.Location{}}
+	cal := &Calendar{
+		Name:   unescapeText(root.Value("X-WR-CALNAME")),
+		Method: strings.ToUpper(root.Value("METHOD")),
+	}
+	for _, c := range root.Components {
+		if c.Name == "VTIMEZONE" {
+			d.addZone(c)
+		}
+	}
+
+	var invalid []InvalidEvent
+	for _, c := range root.Components {
+		if c.Name != "VEVENT" {
+			continue
+		}
+		event, err := d.event(c)
+		if err != nil {
+			invalid = append(invalid, InvalidEvent{UID: c.Value("UID"), Err: err})
+			continue
+		}
+		cal.Events = append(cal.Events, event)
+	}
+	return cal, invalid, nil
+}
+
+type decoder struct {
+	loc      *time.Location
+	zones    map[string]*time.Location // resolved TZIDs
+	fallback map[string]*time.Location // VTIMEZONE standard offsets by TZID
+}
+
+func (d *decoder) addZone(c *Component) {
+	tzid := c.Value("TZID")
+	if tzid == "" {
+		return
+	}
+	var offset string
+	for _, observance := range c.Components {
+		if observance.Name == "STANDARD" || offset == "" {
+			offset = observance.Value("TZOFFSETTO")
+		}
+	}
+	if seconds, err := parseOffset(offset); err == nil {
+		d.fallback[tzid] = time.FixedZone(tzid, seconds)
+	}
+}
+
+func (d *decoder) event(c *Component) (Event, error) {
+	event := Event{
+		UID:         c.Value("UID"),
+		Summary:     unescapeText(c.Value("SUMMARY")),
+		Description: unescapeText(c.Value("DESCRIPTION")),
+		Location:    unescapeText(c.Value("LOCATION")),
+		Status:      strings.ToUpper(c.Value("STATUS")),
+		RRule:       c.Value("RRULE"),
+		Transparent: strings.EqualFold(c.Value("TRANSP"), "TRANSPARENT"),
+	}
+	if event.UID == "" {
+		return event, errors.New("missing UID")
+	}
+	if seq := c.Value("SEQUENCE"); seq != "" {
+		event.Sequence, _ = strconv.Atoi(seq)
+	}
+	if p := c.Prop("DTSTAMP"); p != nil {
+		event.Stamp, _, _ = d.time(p.Params, p.Value)
+	}
+
+	start := c.Prop("DTSTART")
+	if start == nil {
+		return event, errors.New("missing DTSTART")
+	}
+	var allDay bool
+	var err error
+	event.Start, allDay, err = d.time(start.Params, start.Value)
+	if err != nil {
+		return event, err
+	}
+	switch {
+	case c.Prop("DTEND") != nil:
+		end := c.Prop("DTEND")
+		if event.End, _, err = d.time(end.Params, end.Value); err != nil {
+			return event, err
+		}
+	case c.Prop("DURATION") != nil:
+		duration, err := parseDuration(c.Value("DURATION"))
+		if err != nil {
+			return event, err
+		}
+		event.End = event.Start.Add(duration)
+	case allDay:
+		event.End = event.Start.AddDate(0, 0, 1)
+	default:
+		event.End = event.Start
+	}
+	if event.End.Before(event.Start) {
+		return event, errors.New("DTEND is before DTSTART")
+	}
+
+	if p := c.Prop("RECURRENCE-ID"); p != nil {
+		recurrenceID, _, err := d.time(p.Params, p.Value)
+		if err != nil {
+			return event, err
+		}
+		event.RecurrenceID = &recurrenceID
+	}
+	for _, p := range c.Props {
+		switch p.Name {
+		case "EXDATE":
+			for _, value := range strings.Split(p.Value, ",") {
+				exdate, _, err := d.time(p.Params, value)
+				if err != nil {
+					return event, err
+				}
+				event.ExDates = append(event.ExDates, exdate)
+			}
+		case "ORGANIZER":
+			organizer := attendee(p)
+			event.Organizer = &organizer
+		case "ATTENDEE":
+			event.Attendees = append(event.Attendees, attendee(p))
+		}
+	}
+	return event, nil
+}
+
+// attendee reads an ORGANIZER or ATTENDEE property. Addresses that are not
+// mailto: URIs are kept as they are.
+func attendee(p Property) Attendee {
+	email := p.Value
+	if len(email) >= len("mailto:") && strings.EqualFold(email[:len("mailto:")], "mailto:") {
+		email = email[len("mailto:"):]
+	}
+	return Attendee{
+		Email:    email,
+		Name:     p.Params["CN"],
+		Role:     strings.ToUpper(p.Params["ROLE"]),
+		PartStat: strings.ToUpper(p.Params["PARTSTAT"]),
+		RSVP:     strings.EqualFold(p.Params["RSVP"], "TRUE"),
+	}
+}
+
+// time reads a DATE or DATE-TIME value, reporting whether it was a date.
+func (d *decoder) time(params map[string]string, value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, d.loc)
+		if err != nil {
+			return t, true, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		if err != nil {
+			return t, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, false, nil
+	}
+	loc := d.loc
+	if tzid := params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = d.zone(tzid); err != nil {
+			return time.Time{}, false, err
+		}
+	}
+	t, err := time.ParseInLocation(localLayout, value, loc)
+	if err != nil {
+		return t, false, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t, false, nil
+}
+
+func (d *decoder) zone(tzid string) (*time.Location, error) {
+	if loc, ok := d.zones[tzid]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+	if err != nil {
+		var ok bool
+		if loc, ok = d.fallback[tzid]; !ok {
+			return nil, fmt.Errorf("unknown time zone %q", tzid)
+		}
+	}
+	d.zones[tzid] = loc
+	return loc, nil
+}
+
+func parseOffset(value string) (int, error) {
+	if len(value) != 5 && len(value) != 7 {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	sign := 1
+	switch value[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	hours, err1 := strconv.Atoi(value[1:3])
+	minutes, err2 := strconv.Atoi(value[3:5])
+	seconds := 0
+	var err3 error
+	if len(value) == 7 {
+		seconds, err3 = strconv.Atoi(value[5:7])
+	}
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	return sign * (hours*3600 + minutes*60 + seconds), nil
+}
+
+// parseDuration reads an RFC 5545 DURATION such as "PT1H30M" or "P1W".
+func parseDuration(value string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid duration %q", value)
+	s := strings.ToUpper(strings.TrimSpace(value))
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+	s = strings.TrimLeft(s, "+-")
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, invalid
+	}
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	var total time.Duration
+	number := ""
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'T':
+			units = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		default:
+			unit, ok := units[c]
+			n, err := strconv.Atoi(number)
+			if !ok || err != nil {
+				return 0, invalid
+			}
+			total += time.Duration(n) * unit
+			number = ""
+		}
+	}
+	if number != "" {
+		return 0, invalid
+	}
+	return sign * total, nil
+}
@@ -0,0 +1,166 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Example//EN\r\n" +
+	"X-WR-CALNAME:Work\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:Custom Standard Time\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:16010101T000000\r\n" +
+	"TZOFFSETFROM:+0100\r\n" +
+	"TZOFFSETTO:+0100\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"DTSTART;TZID=Asia/Kolkata:20250804T090000\r\n" +
+	"DURATION:PT30M\r\n" +
+	"RRULE:FREQ=DAILY;COUNT=10\r\n" +
+	"EXDATE;TZID=Asia/Kolkata:20250805T090000,20250806T090000\r\n" +
+	"SUMMARY:Stand\r\n" +
+	"  up\\, daily\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"RECURRENCE-ID;TZID=Asia/Kolkata:20250807T090000\r\n" +
+	"DTSTART:20250807T050000Z\r\n" +
+	"DTEND:20250807T053000Z\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:offsite@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20250808\r\n" +
+	"TRANSP:TRANSPARENT\r\n" +
+	"ATTENDEE;CN=\"Doe: Jane\";PARTSTAT=declined:MAILTO:jane@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review@example.com\r\n" +
+	"DTSTART;TZID=Custom Standard Time:20250808T140000\r\n" +
+	"DTEND;TZID=Custom Standard Time:20250808T150000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:broken@example.com\r\n" +
+	"SUMMARY:No start\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestDecode(t *testing.T) {
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+	cal, invalid, err := Decode(strings.NewReader(sample), ist)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cal.Name != "Work" || len(cal.Events) != 4 {
+		t.Fatalf("Expected 4 events in Work, got %q with %d", cal.Name, len(cal.Events))
+	}
+	if len(invalid) != 1 || invalid[0].UID != "broken@example.com" {
+		t.Errorf("Expected the event without DTSTART to be invalid, got %+v", invalid)
+	}
+
+	series := cal.Events[0]
+	start := time.Date(2025, 8, 4, 9, 0, 0, 0, ist)
+	if series.Summary != "Stand up, daily" || !series.Start.Equal(start) || !series.End.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("Unexpected series %+v", series)
+	}
+	if series.RRule != "FREQ=DAILY;COUNT=10" || len(series.ExDates) != 2 || !series.ExDates[1].Equal(start.AddDate(0, 0, 2)) {
+		t.Errorf("Expected the rule and two EXDATEs, got %q %v", series.RRule, series.ExDates)
+	}
+
+	cancelled := cal.Events[1]
+	if cancelled.Status != "CANCELLED" || cancelled.RecurrenceID == nil || !cancelled.RecurrenceID.Equal(start.AddDate(0, 0, 3)) {
+		t.Errorf("Expected a cancelled occurrence on 7 August, got %+v", cancelled)
+	}
+
+	// All-day events last a day in the given zone
+	allDay := cal.Events[2]
+	if !allDay.Transparent || !allDay.Start.Equal(time.Date(2025, 8, 8, 0, 0, 0, 0, ist)) || allDay.End.Sub(allDay.Start) != 24*time.Hour {
+		t.Errorf("Unexpected all-day event %+v", allDay)
+	}
+	if len(allDay.Attendees) != 1 || allDay.Attendees[0] != (Attendee{Email: "jane@example.com", Name: "Doe: Jane", PartStat: "DECLINED"}) {
+		t.Errorf("Unexpected attendees %+v", allDay.Attendees)
+	}
+
+	// Zones that are not IANA names use the VTIMEZONE's standard offset
+	review := cal.Events[3]
+	if !review.Start.Equal(time.Date(2025, 8, 8, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 13:00 UTC, got %v", review.Start)
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No zone data: %v", err)
+	}
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, newYork)
+	cal := &Calendar{
+		TimeZone: newYork,
+		Events: []Event{{
+			UID:         "a@b",
+			Stamp:       start,
+			Start:       start,
+			End:         start.Add(time.Hour),
+			Summary:     "Planning; Q3, Q4",
+			Description: "Line one\nLine two " + strings.Repeat("long ", 20),
+			RRule:       "FREQ=WEEKLY;COUNT=3",
+			ExDates:     []time.Time{start.AddDate(0, 0, 7)},
+		}},
+	}
+	var out bytes.Buffer
+	if err := cal.Encode(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decoded, invalid, err := Decode(&out, nil)
+	if err != nil || len(invalid) != 0 || len(decoded.Events) != 1 {
+		t.Fatalf("Expected one event back, got %+v, %v, %v", decoded, invalid, err)
+	}
+	got, want := decoded.Events[0], cal.Events[0]
+	if got.Summary != want.Summary || got.Description != want.Description || !got.Start.Equal(want.Start) ||
+		!got.End.Equal(want.End) || got.RRule != want.RRule || len(got.ExDates) != 1 || !got.ExDates[0].Equal(want.ExDates[0]) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"BEGIN:VEVENT\r\nEND:VEVENT\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nno colon here\r\nEND:VCALENDAR\r\n",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT30M":     30 * time.Minute,
+		"PT1H30M":   90 * time.Minute,
+		"P1D":       24 * time.Hour,
+		"P1W":       7 * 24 * time.Hour,
+		"P1DT2H":    26 * time.Hour,
+		"-PT15M":    -15 * time.Minute,
+		"+PT10S":    10 * time.Second,
+		"P2DT0H30M": 48*time.Hour + 30*time.Minute,
+	}
+	for value, expected := range tests {
+		if got, err := parseDuration(value); err != nil || got != expected {
+			t.Errorf("%s: expected %v, got %v, %v", value, expected, got, err)
+		}
+	}
+	for _, value := range []string{"", "P", "PT", "1H", "PT1X", "PT1"} {
+		if _, err := parseDuration(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package model
 
 import (
+	"smart-scheduler/recurrence"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Event struct {
@@ -11,4 +15,96 @@ type Event struct {
 	Title     string    `json:"title"`
 	StartTime time.Time `json:"startTime"`
 	EndTime   time.Time `json:"endTime"`
+
+	// Recurring series. StartTime/EndTime describe the first occurrence.
+	RRule     string     `gorm:"column:rrule" json:"rrule,omitempty"`
+	ExDates   string     `json:"exdates,omitempty"` // comma-separated RFC3339 starts of skipped occurrences
+	SeriesEnd *time.Time `json:"-"`                 // end of the last occurrence, nil when the series never ends
+
+	// Set on overrides of a single occurrence, and on expanded occurrences
+	RecurringEventID *uint      `gorm:"index" json:"recurringEventId,omitempty"`
+	RecurrenceID     *time.Time `json:"recurrenceId,omitempty"` // original start of the occurrence
+
+	// Set on events imported from another calendar: the iCalendar UID and
+	// the name of the feed it came from
+	UID    string `gorm:"column:uid" json:"uid,omitempty"`
+	Source string `json:"source,omitempty"`
+
+	// Shown on the calendar but free time for scheduling, like iCalendar's
+	// TRANSP:TRANSPARENT
+	Transparent bool `json:"transparent,omitempty"`
+
+	// Set when the entry is derived from a meeting the user attends, with
+	// the user's own RSVP
+	Meeting        *Meeting `gorm:"-" json:"meeting,omitempty"`
+	ResponseStatus string   `gorm:"-" json:"responseStatus,omitempty"`
+}
+
+// IsRecurring reports whether the event is the master row of a series.
+func (e Event) IsRecurring() bool {
+	return e.RRule != ""
+}
+
+// ExceptionDates parses ExDates into the starts of the skipped occurrences.
+func (e Event) ExceptionDates() []time.Time {
+	var dates []time.Time
+	for _, value := range strings.Split(e.ExDates, ",") {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(value)); err == nil {
+			dates = append(dates, t)
+		}
+	}
+	return dates
+}
+
+// IsExcluded reports whether the occurrence starting at t was removed with
+// an EXDATE.
+func (e Event) IsExcluded(t time.Time) bool {
+	for _, d := range e.ExceptionDates() {
+		if d.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddExDate records t as a skipped occurrence.
+func (e *Event) AddExDate(t time.Time) {
+	if e.IsExcluded(t) {
+		return
+	}
+	value := t.UTC().Format(time.RFC3339)
+	if e.ExDates == "" {
+		e.ExDates = value
+	} else {
+		e.ExDates += "," + value
+	}
+}
+
+// BeforeSave keeps SeriesEnd in step with the recurrence rule so that range
+// queries can find series without expanding them.
+func (e *Event) BeforeSave(tx *gorm.DB) error {
+	seriesEnd, err := computeSeriesEnd(e.RRule, e.StartTime, e.EndTime)
+	if err != nil {
+		return err
+	}
+	e.SeriesEnd = seriesEnd
+	return nil
+}
+
+// computeSeriesEnd returns when the last occurrence of a series ends, or nil
+// for one-off entries and series that never end.
+func computeSeriesEnd(rrule string, start, end time.Time) (*time.Time, error) {
+	if rrule == "" {
+		return nil, nil
+	}
+	rule, err := recurrence.Parse(rrule, start.Location())
+	if err != nil {
+		return nil, err
+	}
+	last, ok := rule.Last(start)
+	if !ok {
+		return nil, nil
+	}
+	seriesEnd := last.Add(end.Sub(start))
+	return &seriesEnd, nil
 }
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// Hold is a tentative reservation on a participant's calendar. All holds
+// placed for the same proposed meeting share a HoldCode and are confirmed or
+// released together.
+type Hold struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	HoldCode  string    `gorm:"index;not null" json:"holdCode"`
+	UserID    string    `gorm:"index" json:"userId"`
+	Optional  bool      `json:"optional,omitempty"` // held for an optional attendee
+	Title     string    `json:"title"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	ExpiresAt time.Time `gorm:"index" json:"expiresAt"`
+
+	// Meeting details carried over when the hold is confirmed
+	OrganizerID string `json:"organizerId,omitempty"`
+	Description string `json:"description,omitempty"`
+	Location    string `json:"location,omitempty"`
+}
+
+// Active reports whether the hold still blocks the slot at the given time.
+func (h Hold) Active(now time.Time) bool {
+	return now.Before(h.ExpiresAt)
+}
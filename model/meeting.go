@@ -0,0 +1,134 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RSVP states of a meeting attendee
+const (
+	RSVPNeedsAction = "needs-action"
+	RSVPAccepted    = "accepted"
+	RSVPDeclined    = "declined"
+	RSVPTentative   = "tentative"
+)
+
+// IsRSVPStatus reports whether status is one of the RSVP states.
+func IsRSVPStatus(status string) bool {
+	switch status {
+	case RSVPNeedsAction, RSVPAccepted, RSVPDeclined, RSVPTentative:
+		return true
+	}
+	return false
+}
+
+// Meeting is a single booked meeting shared by all of its attendees. Each
+// attendee's calendar shows it without a per-user copy being stored.
+type Meeting struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	MeetingCode string    `gorm:"unique;not null" json:"meetingCode"`
+	OrganizerID string    `json:"organizerId"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+
+	RRule     string     `gorm:"column:rrule" json:"rrule,omitempty"`
+	SeriesEnd *time.Time `json:"-"`
+
+	// iTIP SEQUENCE, incremented each time attendees are sent an update
+	Sequence int `json:"sequence"`
+
+	Attendees []MeetingAttendee `gorm:"foreignKey:MeetingID;constraint:OnDelete:CASCADE" json:"attendees"`
+	// Rooms and equipment booked for the meeting
+	Resources []MeetingResource `gorm:"foreignKey:MeetingID;constraint:OnDelete:CASCADE" json:"resources,omitempty"`
+}
+
+type MeetingAttendee struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	MeetingID uint   `gorm:"index;not null" json:"meetingId"`
+	UserID    string `gorm:"index;not null" json:"userId"`
+	Optional  bool   `json:"optional"`
+	Status    string `json:"status"`
+}
+
+// AttendeeIDs returns the user codes of everyone invited to the meeting.
+func (m Meeting) AttendeeIDs() []string {
+	ids := make([]string, 0, len(m.Attendees))
+	for _, a := range m.Attendees {
+		ids = append(ids, a.UserID)
+	}
+	return ids
+}
+
+// Attendee returns userId's entry in the attendee list, or nil when they are
+// not invited.
+func (m Meeting) Attendee(userId string) *MeetingAttendee {
+	for i := range m.Attendees {
+		if m.Attendees[i].UserID == userId {
+			return &m.Attendees[i]
+		}
+	}
+	return nil
+}
+
+// ResourceIDs returns the codes of the resources booked for the meeting.
+func (m Meeting) ResourceIDs() []string {
+	ids := make([]string, 0, len(m.Resources))
+	for _, r := range m.Resources {
+		ids = append(ids, r.ResourceID)
+	}
+	return ids
+}
+
+// RequiredIDs returns the user codes of attendees who must attend.
+func (m Meeting) RequiredIDs() []string {
+	var ids []string
+	for _, a := range m.Attendees {
+		if !a.Optional {
+			ids = append(ids, a.UserID)
+		}
+	}
+	return ids
+}
+
+// OptionalIDs returns the user codes of optional attendees.
+func (m Meeting) OptionalIDs() []string {
+	var ids []string
+	for _, a := range m.Attendees {
+		if a.Optional {
+			ids = append(ids, a.UserID)
+		}
+	}
+	return ids
+}
+
+// EventFor presents the meeting as an entry on userId's calendar.
+func (m Meeting) EventFor(userId string) Event {
+	meeting := m
+	event := Event{
+		EventCode: m.MeetingCode,
+		UserID:    userId,
+		Title:     m.Title,
+		StartTime: m.StartTime,
+		EndTime:   m.EndTime,
+		RRule:     m.RRule,
+		SeriesEnd: m.SeriesEnd,
+		Meeting:   &meeting,
+	}
+	if a := m.Attendee(userId); a != nil {
+		event.ResponseStatus = a.Status
+	}
+	return event
+}
+
+func (m *Meeting) BeforeSave(tx *gorm.DB) error {
+	seriesEnd, err := computeSeriesEnd(m.RRule, m.StartTime, m.EndTime)
+	if err != nil {
+		return err
+	}
+	m.SeriesEnd = seriesEnd
+	return nil
+}
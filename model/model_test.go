@@ -377,3 +377,207 @@ func TestEventJSONFormatting(t *testing.T) {
 		}
 	}
 }
+
+func TestHoldActive(t *testing.T) {
+	now := time.Date(2025, 8, 9, 10, 0, 0, 0, getISTTimezone())
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		active    bool
+	}{
+		{
+			name:      "Expires in the future",
+			expiresAt: now.Add(15 * time.Minute),
+			active:    true,
+		},
+		{
+			name:      "Expires exactly now",
+			expiresAt: now,
+			active:    false,
+		},
+		{
+			name:      "Already expired",
+			expiresAt: now.Add(-time.Minute),
+			active:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hold := Hold{HoldCode: "hold-1", UserID: "user1", ExpiresAt: tt.expiresAt}
+			if hold.Active(now) != tt.active {
+				t.Errorf("Expected active %v, got %v", tt.active, hold.Active(now))
+			}
+		})
+	}
+}
+
+func TestUserAvailable(t *testing.T) {
+	ist := getISTTimezone()
+	// Saturday 9 August 2025
+	weekdayHours := User{
+		UserCode: "user1",
+		WorkingHours: []WorkingHours{
+			{Weekday: time.Saturday, StartMinute: 9 * 60, EndMinute: 17 * 60},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		user      User
+		start     time.Time
+		end       time.Time
+		available bool
+	}{
+		{
+			name:      "No working hours means always available",
+			user:      User{UserCode: "user2"},
+			start:     time.Date(2025, 8, 9, 22, 0, 0, 0, ist),
+			end:       time.Date(2025, 8, 9, 23, 0, 0, 0, ist),
+			available: true,
+		},
+		{
+			name:      "Inside window",
+			user:      weekdayHours,
+			start:     time.Date(2025, 8, 9, 9, 0, 0, 0, ist),
+			end:       time.Date(2025, 8, 9, 10, 0, 0, 0, ist),
+			available: true,
+		},
+		{
+			name:      "Ends exactly at window end",
+			user:      weekdayHours,
+			start:     time.Date(2025, 8, 9, 16, 0, 0, 0, ist),
+			end:       time.Date(2025, 8, 9, 17, 0, 0, 0, ist),
+			available: true,
+		},
+		{
+			name:      "Runs past window end",
+			user:      weekdayHours,
+			start:     time.Date(2025, 8, 9, 16, 30, 0, 0, ist),
+			end:       time.Date(2025, 8, 9, 17, 30, 0, 0, ist),
+			available: false,
+		},
+		{
+			name:      "Wrong weekday",
+			user:      weekdayHours,
+			start:     time.Date(2025, 8, 10, 10, 0, 0, 0, ist),
+			end:       time.Date(2025, 8, 10, 11, 0, 0, 0, ist),
+			available: false,
+		},
+		{
+			name: "Evaluated in the user's zone",
+			user: User{
+				UserCode: "user3",
+				Timezone: "UTC",
+				WorkingHours: []WorkingHours{
+					{Weekday: time.Saturday, StartMinute: 9 * 60, EndMinute: 17 * 60},
+				},
+			},
+			// 09:00 IST is 03:30 UTC, before the window opens
+			start:     time.Date(2025, 8, 9, 9, 0, 0, 0, ist),
+			end:       time.Date(2025, 8, 9, 10, 0, 0, 0, ist),
+			available: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.Available(tt.start, tt.end); got != tt.available {
+				t.Errorf("Expected available %v, got %v", tt.available, got)
+			}
+		})
+	}
+}
+
+func TestEventExDates(t *testing.T) {
+	ist := getISTTimezone()
+	first := time.Date(2025, 8, 11, 9, 0, 0, 0, ist)
+	second := time.Date(2025, 8, 18, 9, 0, 0, 0, ist)
+
+	event := Event{EventCode: "series", RRule: "FREQ=WEEKLY"}
+	if !event.IsRecurring() {
+		t.Error("Expected event with RRule to be recurring")
+	}
+
+	event.AddExDate(first)
+	event.AddExDate(second)
+	event.AddExDate(first) // duplicates are ignored
+
+	if len(event.ExceptionDates()) != 2 {
+		t.Fatalf("Expected 2 exception dates, got %d (%s)", len(event.ExceptionDates()), event.ExDates)
+	}
+	// Stored in UTC but matched by instant
+	if !event.IsExcluded(first) || !event.IsExcluded(second.UTC()) {
+		t.Error("Expected both dates to be excluded")
+	}
+	if event.IsExcluded(first.Add(time.Hour)) {
+		t.Error("Did not expect an unrelated time to be excluded")
+	}
+}
+
+func TestMeetingEventFor(t *testing.T) {
+	start := time.Date(2025, 8, 9, 10, 0, 0, 0, getISTTimezone())
+	meeting := Meeting{
+		ID:          3,
+		MeetingCode: "meeting-1",
+		OrganizerID: "user1",
+		Title:       "Planning",
+		Location:    "Room 4",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		Attendees: []MeetingAttendee{
+			{MeetingID: 3, UserID: "user1", Status: RSVPAccepted},
+			{MeetingID: 3, UserID: "user2", Status: RSVPNeedsAction},
+		},
+	}
+
+	ids := meeting.AttendeeIDs()
+	if len(ids) != 2 || ids[0] != "user1" || ids[1] != "user2" {
+		t.Errorf("Unexpected attendee ids: %v", ids)
+	}
+
+	event := meeting.EventFor("user2")
+	if event.UserID != "user2" || event.EventCode != "meeting-1" || event.Title != "Planning" {
+		t.Errorf("Unexpected calendar entry: %+v", event)
+	}
+	if !event.StartTime.Equal(meeting.StartTime) || !event.EndTime.Equal(meeting.EndTime) {
+		t.Error("Calendar entry should keep the meeting's times")
+	}
+	if event.Meeting == nil || len(event.Meeting.Attendees) != 2 {
+		t.Fatal("Calendar entry should carry the meeting and its attendees")
+	}
+
+	// Attendee info is part of the calendar JSON
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(jsonData, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal event: %v", err)
+	}
+	if _, ok := decoded["meeting"]; !ok {
+		t.Error("Expected meeting details in event JSON")
+	}
+}
+
+func TestUserResolveLocation(t *testing.T) {
+	user := User{UserCode: "user1", Timezone: "America/New_York"}
+	user.ResolveLocation()
+	if loc := user.Location(); loc == nil || loc.String() != "America/New_York" {
+		t.Fatalf("Expected the resolved zone, got %v", loc)
+	}
+
+	// A copy keeps the resolved zone, and a changed Timezone is looked up again
+	copied := user
+	copied.Timezone = "Europe/Berlin"
+	if loc := copied.Location(); loc == nil || loc.String() != "Europe/Berlin" {
+		t.Errorf("Expected the new zone, got %v", loc)
+	}
+	copied.Timezone = "Mars/Olympus"
+	copied.ResolveLocation()
+	if loc := copied.Location(); loc != nil {
+		t.Errorf("Expected no zone for an unknown name, got %v", loc)
+	}
+}
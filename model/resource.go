@@ -0,0 +1,59 @@
+package model
+
+import "strings"
+
+// Resource types the scheduler knows about. Other types can be stored and
+// booked by code, but only rooms are picked automatically by default.
+const (
+	ResourceRoom      = "room"
+	ResourceProjector = "projector"
+)
+
+// Resource is a bookable room or piece of equipment. Its calendar is made of
+// the meetings that booked it.
+type Resource struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	ResourceCode string `gorm:"unique;not null" json:"resourceCode"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`               // "room", "projector", ...
+	Capacity     int    `json:"capacity"`           // seats, 0 for equipment
+	Location     string `json:"location,omitempty"` // building or floor
+	Features     string `json:"features,omitempty"` // comma-separated, such as "video,whiteboard"
+}
+
+// FeatureList splits Features into its lower-case entries.
+func (r Resource) FeatureList() []string {
+	var features []string
+	for _, f := range strings.Split(r.Features, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// HasFeatures reports whether the resource offers every one of features,
+// ignoring case.
+func (r Resource) HasFeatures(features []string) bool {
+	have := r.FeatureList()
+	for _, want := range features {
+		found := false
+		for _, f := range have {
+			if strings.EqualFold(f, strings.TrimSpace(want)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// MeetingResource books a resource for a meeting.
+type MeetingResource struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	MeetingID  uint   `gorm:"index;not null" json:"meetingId"`
+	ResourceID string `gorm:"index;not null" json:"resourceId"` // the resource code
+}
@@ -1,8 +1,69 @@
 package model
 
+import (
+	"time"
+)
+
 type User struct {
-	ID       uint    `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserCode string  `gorm:"unique;not null" json:"userCode"` // For custom user IDs like "user1"
-	Name     string  `json:"name"`
-	Events   []Event `gorm:"foreignKey:UserID;references:UserCode"`
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserCode     string         `gorm:"unique;not null" json:"userCode"` // For custom user IDs like "user1"
+	Name         string         `json:"name"`
+	Email        string         `json:"email,omitempty"`        // where meeting invitations are sent
+	Timezone     string         `json:"timezone"`               // IANA zone such as "Asia/Kolkata"
+	Organization string         `json:"organization,omitempty"` // picks the scoring defaults for meetings they organize
+	Events       []Event        `gorm:"foreignKey:UserID;references:UserCode"`
+	WorkingHours []WorkingHours `gorm:"foreignKey:UserID;references:UserCode" json:"workingHours,omitempty"`
+
+	// Zone looked up by ResolveLocation for the Timezone in locFor
+	loc    *time.Location `gorm:"-"`
+	locFor string         `gorm:"-"`
+}
+
+// Location returns the user's time zone, or nil when none is set or the
+// name is not a known IANA zone. Loading a zone is slow, so callers that
+// ask often should call ResolveLocation first.
+func (u User) Location() *time.Location {
+	if u.Timezone == "" {
+		return nil
+	}
+	if u.locFor == u.Timezone {
+		return u.loc
+	}
+	return loadLocation(u.Timezone)
+}
+
+// ResolveLocation looks up the user's time zone once, so that Location
+// and Available answer from the copy held on the user. A later change of
+// Timezone is noticed and looked up again.
+func (u *User) ResolveLocation() {
+	u.loc, u.locFor = loadLocation(u.Timezone), u.Timezone
+}
+
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// Available reports whether [start, end) falls entirely inside one of the
+// user's working-hours windows, evaluated in the user's own time zone. Users
+// without any windows are treated as always available.
+func (u User) Available(start, end time.Time) bool {
+	if len(u.WorkingHours) == 0 {
+		return true
+	}
+	if loc := u.Location(); loc != nil {
+		start, end = start.In(loc), end.In(loc)
+	}
+	for _, w := range u.WorkingHours {
+		if w.Contains(start, end) {
+			return true
+		}
+	}
+	return false
 }
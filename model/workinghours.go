@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+)
+
+// WorkingHours is one weekly window during which a user accepts meetings.
+// Minutes are counted from local midnight in the user's time zone.
+type WorkingHours struct {
+	ID          uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      string       `gorm:"index;not null" json:"userId"`
+	Weekday     time.Weekday `json:"weekday"`
+	StartMinute int          `json:"startMinute"`
+	EndMinute   int          `json:"endMinute"`
+}
+
+// Contains reports whether [start, end) lies inside the window. Both times
+// must already be in the owner's local zone and on the same calendar day.
+func (w WorkingHours) Contains(start, end time.Time) bool {
+	if start.Weekday() != w.Weekday {
+		return false
+	}
+	sy, sm, sd := start.Date()
+	ey, em, ed := end.Date()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if sy != ey || sm != em || sd != ed {
+		// Allow a slot that ends exactly at the following midnight
+		if !end.Equal(time.Date(sy, sm, sd+1, 0, 0, 0, 0, start.Location())) {
+			return false
+		}
+		endMinute = 24 * 60
+	}
+	return startMinute >= w.StartMinute && endMinute <= w.EndMinute
+}
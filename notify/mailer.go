@@ -0,0 +1,216 @@
+// Package notify sends meeting invitations as RFC 6047 iMIP emails.
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is an email carrying an iTIP calendar object next to a plain text
+// summary for mail clients without calendar support.
+type Message struct {
+	From     string
+	ReplyTo  string
+	To       []string
+	Subject  string
+	Text     string
+	Method   string // iTIP method of Calendar, such as "REQUEST" or "CANCEL"
+	Calendar []byte
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Bytes renders the message as a MIME multipart/alternative email whose
+// text/calendar part carries the iTIP method, as iMIP requires.
+func (m Message) Bytes() ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "text/plain; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	if err := writePart(parts, header, []byte(m.Text)); err != nil {
+		return nil, err
+	}
+	header = textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType("text/calendar", map[string]string{"method": m.Method, "charset": "UTF-8"}))
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	if err := writePart(parts, header, m.Calendar); err != nil {
+		return nil, err
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	writeHeader := func(name, value string) {
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, value)
+	}
+	writeHeader("From", m.From)
+	writeHeader("To", strings.Join(m.To, ", "))
+	if m.ReplyTo != "" {
+		writeHeader("Reply-To", m.ReplyTo)
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("UTF-8", m.Subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID(m.From))
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": parts.Boundary()}))
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func writePart(parts *multipart.Writer, header textproto.MIMEHeader, content []byte) error {
+	w, err := parts.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write(content); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if _, host, ok := strings.Cut(addr.Address, "@"); ok {
+			domain = host
+		}
+	}
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(suffix), domain)
+}
+
+// DefaultSMTPTimeout bounds a whole SMTP exchange when SMTPMailer.Timeout
+// is not set.
+const DefaultSMTPTimeout = 30 * time.Second
+
+// SMTPMailer sends messages through an SMTP server, upgrading to TLS when
+// the server offers STARTTLS and authenticating with PLAIN auth when a
+// username is set.
+type SMTPMailer struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	Timeout  time.Duration // for connecting and sending one message
+}
+
+func (s *SMTPMailer) Send(msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	from := msg.From
+	if addr, err := mail.ParseAddress(msg.From); err == nil {
+		from = addr.Address
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSMTPTimeout
+	}
+	conn, err := net.DialTimeout("tcp", s.Addr, timeout)
+	if err != nil {
+		return err
+	}
+	// The deadline covers the whole exchange, so a server that stops
+	// answering cannot hold the sender up
+	conn.SetDeadline(time.Now().Add(timeout))
+	host, _, _ := strings.Cut(s.Addr, ":")
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// FileMailer writes each message to Dir as an .eml file that mail and
+// calendar clients can open, for trying invitations out locally.
+type FileMailer struct {
+	Dir string
+
+	mu    sync.Mutex
+	count int
+}
+
+func (f *FileMailer) Send(msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.count++
+	name := fmt.Sprintf("%s-%03d-%s.eml", time.Now().Format("20060102T150405"), f.count, strings.ToLower(msg.Method))
+	f.mu.Unlock()
+	path := filepath.Join(f.Dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	log.Printf("Wrote %s invitation for %s to %s", msg.Method, strings.Join(msg.To, ", "), path)
+	return nil
+}
+
+// LogMailer logs each message instead of sending it.
+type LogMailer struct{}
+
+func (LogMailer) Send(msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Calendar)
+	return nil
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMessage() Message {
+	return Message{
+		From:     "Smart Scheduler <scheduler@example.com>",
+		ReplyTo:  "alice@example.com",
+		To:       []string{"bob@example.com", "charlie@example.com"},
+		Subject:  "Invitation: Café planning",
+		Text:     "You have been invited.",
+		Method:   "REQUEST",
+		Calendar: []byte("BEGIN:VCALENDAR\r\nMETHOD:REQUEST\r\nSUMMARY:" + strings.Repeat("long ", 20) + "\r\nEND:VCALENDAR\r\n"),
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	msg := testMessage()
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Expected subject %q, got %q, %v", msg.Subject, subject, err)
+	}
+	if to := parsed.Header.Get("To"); to != "bob@example.com, charlie@example.com" {
+		t.Errorf("Unexpected To %q", to)
+	}
+	if !strings.HasSuffix(parsed.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Expected a Message-ID in the sender's domain, got %q", parsed.Header.Get("Message-ID"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %q, %v", mediaType, err)
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var types []string
+	var calendar string
+	for {
+		part, err := parts.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(quotedprintable.NewReader(part))
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/calendar") {
+			calendar = string(body)
+		}
+	}
+	if len(types) != 2 || types[0] != "text/plain; charset=UTF-8" || types[1] != "text/calendar; charset=UTF-8; method=REQUEST" {
+		t.Errorf("Unexpected parts %v", types)
+	}
+	if calendar != string(msg.Calendar) {
+		t.Errorf("Expected the calendar to survive encoding, got %q", calendar)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := &FileMailer{Dir: dir}
+	for i := 0; i < 2; i++ {
+		if err := mailer.Send(testMessage()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*-request.eml"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("Expected two .eml files, got %v, %v", files, err)
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), "method=REQUEST") {
+		t.Errorf("Expected an iMIP message, got\n%s", data)
+	}
+}
+
+func TestSMTPMailerTimeout(t *testing.T) {
+	// A server that accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	mailer := &SMTPMailer{Addr: listener.Addr().String(), Timeout: 100 * time.Millisecond}
+	began := time.Now()
+	if err := mailer.Send(testMessage()); err == nil {
+		t.Fatal("Expected a silent server to fail the send")
+	}
+	if took := time.Since(began); took > 5*time.Second {
+		t.Errorf("Expected the send to give up after the timeout, took %v", took)
+	}
+}
@@ -0,0 +1,302 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxIterations bounds how many periods are walked while expanding a rule so
+// that an unbounded rule queried far into the future cannot spin forever.
+const maxIterations = 100000
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule is the subset of an RFC 5545 RRULE supported by the scheduler:
+// FREQ, INTERVAL, COUNT, UNTIL and BYDAY (plain weekdays only).
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	Count    int       // zero when unbounded by count
+	Until    time.Time // zero when unbounded by date
+	ByDay    []time.Weekday
+}
+
+// Parse reads an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=8". A
+// leading "RRULE:" prefix is accepted. Floating and date-only UNTIL values
+// are read in loc, the zone of the series' DTSTART.
+func Parse(value string, loc *time.Location) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, errors.New("empty recurrence rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch f := Frequency(strings.ToUpper(val)); f {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = f
+			default:
+				return nil, fmt.Errorf("unsupported recurrence frequency %q", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid recurrence interval %q", val)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid recurrence count %q", val)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(val, loc)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, ok := weekdayCodes[strings.ToUpper(code)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported recurrence weekday %q", code)
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "WKST":
+			if strings.ToUpper(val) != "MO" {
+				return nil, fmt.Errorf("unsupported recurrence week start %q", val)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("recurrence rule is missing FREQ")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, errors.New("recurrence rule cannot have both COUNT and UNTIL")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly && rule.Freq != Daily {
+		return nil, fmt.Errorf("BYDAY is only supported with DAILY or WEEKLY recurrence")
+	}
+	return rule, nil
+}
+
+func parseUntil(val string, loc *time.Location) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", time.RFC3339} {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("20060102T150405", val, loc); err == nil {
+		return t, nil
+	}
+	// A date includes every occurrence on that day
+	if t, err := time.ParseInLocation("20060102", val, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid recurrence end %q", val)
+}
+
+// String renders the rule back into RRULE value syntax.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			codes = append(codes, strings.ToUpper(day.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Bounded reports whether the rule produces a finite number of occurrences.
+func (r *Rule) Bounded() bool {
+	return r.Count > 0 || !r.Until.IsZero()
+}
+
+// Each calls fn with the start of every occurrence of a series beginning at
+// dtstart, in order, until fn returns false or the rule is exhausted.
+// dtstart itself is only emitted when it matches the rule: a weekly BYDAY=MO
+// series starting on a Tuesday first occurs the following Monday.
+func (r *Rule) Each(dtstart time.Time, fn func(time.Time) bool) {
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if t.Before(dtstart) {
+			return true
+		}
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		if r.Count > 0 && emitted >= r.Count {
+			return false
+		}
+		emitted++
+		return fn(t)
+	}
+
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	loc := dtstart.Location()
+	days := r.sortedByDay()
+
+	for i := 0; i < maxIterations; i++ {
+		n := i * r.Interval
+		switch r.Freq {
+		case Daily:
+			t := time.Date(y, m, d+n, hh, mm, ss, dtstart.Nanosecond(), loc)
+			if len(days) > 0 && !containsDay(days, t.Weekday()) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		case Weekly:
+			if len(days) == 0 {
+				if !emit(time.Date(y, m, d+7*n, hh, mm, ss, dtstart.Nanosecond(), loc)) {
+					return
+				}
+				continue
+			}
+			// Weeks start on Monday (WKST=MO)
+			monday := d - (int(dtstart.Weekday())+6)%7
+			for _, day := range days {
+				offset := (int(day) + 6) % 7
+				if !emit(time.Date(y, m, monday+7*n+offset, hh, mm, ss, dtstart.Nanosecond(), loc)) {
+					return
+				}
+			}
+		case Monthly:
+			t := time.Date(y, m+time.Month(n), d, hh, mm, ss, dtstart.Nanosecond(), loc)
+			if t.Day() != d {
+				continue // e.g. the 31st in a 30-day month
+			}
+			if !emit(t) {
+				return
+			}
+		case Yearly:
+			t := time.Date(y+n, m, d, hh, mm, ss, dtstart.Nanosecond(), loc)
+			if t.Day() != d {
+				continue // 29 February in a non-leap year
+			}
+			if !emit(t) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Between returns the starts of occurrences lasting duration that overlap
+// [from, to).
+func (r *Rule) Between(dtstart time.Time, duration time.Duration, from, to time.Time) []time.Time {
+	var starts []time.Time
+	r.Each(dtstart, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if t.Add(duration).After(from) {
+			starts = append(starts, t)
+		}
+		return true
+	})
+	return starts
+}
+
+// Last returns the start of the final occurrence, or false when the rule is
+// unbounded.
+func (r *Rule) Last(dtstart time.Time) (time.Time, bool) {
+	if !r.Bounded() {
+		return time.Time{}, false
+	}
+	var last time.Time
+	r.Each(dtstart, func(t time.Time) bool {
+		last = t
+		return true
+	})
+	return last, !last.IsZero()
+}
+
+// CountBefore returns how many occurrences start strictly before t.
+func (r *Rule) CountBefore(dtstart, t time.Time) int {
+	count := 0
+	r.Each(dtstart, func(occ time.Time) bool {
+		if !occ.Before(t) {
+			return false
+		}
+		count++
+		return true
+	})
+	return count
+}
+
+// Includes reports whether t is the start of one of the rule's occurrences.
+func (r *Rule) Includes(dtstart, t time.Time) bool {
+	found := false
+	r.Each(dtstart, func(occ time.Time) bool {
+		if occ.Equal(t) {
+			found = true
+		}
+		return occ.Before(t)
+	})
+	return found
+}
+
+func (r *Rule) sortedByDay() []time.Weekday {
+	days := append([]time.Weekday(nil), r.ByDay...)
+	// Order by position within a Monday-first week
+	sort.Slice(days, func(i, j int) bool {
+		return (int(days[i])+6)%7 < (int(days[j])+6)%7
+	})
+	return days
+}
+
+func containsDay(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+// getISTTimezone returns IST timezone (UTC+05:30)
+func getISTTimezone() *time.Location {
+	return time.FixedZone("IST", 5*60*60+30*60)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expectError bool
+		expected    string
+	}{
+		{
+			name:     "Daily with count",
+			value:    "FREQ=DAILY;COUNT=5",
+			expected: "FREQ=DAILY;COUNT=5",
+		},
+		{
+			name:     "Weekly with days and interval",
+			value:    "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,MO",
+			expected: "FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,MO",
+		},
+		{
+			name:     "Until date",
+			value:    "FREQ=MONTHLY;UNTIL=20251231T235959Z",
+			expected: "FREQ=MONTHLY;UNTIL=20251231T235959Z",
+		},
+		{
+			name:        "Missing frequency",
+			value:       "COUNT=3",
+			expectError: true,
+		},
+		{
+			name:        "Unsupported frequency",
+			value:       "FREQ=HOURLY",
+			expectError: true,
+		},
+		{
+			name:        "Count and until together",
+			value:       "FREQ=DAILY;COUNT=3;UNTIL=20251231",
+			expectError: true,
+		},
+		{
+			name:        "Ordinal weekday",
+			value:       "FREQ=WEEKLY;BYDAY=1MO",
+			expectError: true,
+		},
+		{
+			name:        "Empty rule",
+			value:       "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.value, time.UTC)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if rule.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, rule.String())
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	ist := getISTTimezone()
+	// Monday 4 August 2025, 10:00 IST
+	dtstart := time.Date(2025, 8, 4, 10, 0, 0, 0, ist)
+	hour := time.Hour
+
+	tests := []struct {
+		name     string
+		rule     string
+		from     time.Time
+		to       time.Time
+		expected []time.Time
+	}{
+		{
+			name: "Daily occurrences in a three day window",
+			rule: "FREQ=DAILY",
+			from: time.Date(2025, 8, 5, 0, 0, 0, 0, ist),
+			to:   time.Date(2025, 8, 8, 0, 0, 0, 0, ist),
+			expected: []time.Time{
+				time.Date(2025, 8, 5, 10, 0, 0, 0, ist),
+				time.Date(2025, 8, 6, 10, 0, 0, 0, ist),
+				time.Date(2025, 8, 7, 10, 0, 0, 0, ist),
+			},
+		},
+		{
+			name: "Count stops the series",
+			rule: "FREQ=DAILY;COUNT=2",
+			from: dtstart,
+			to:   time.Date(2025, 8, 31, 0, 0, 0, 0, ist),
+			expected: []time.Time{
+				time.Date(2025, 8, 4, 10, 0, 0, 0, ist),
+				time.Date(2025, 8, 5, 10, 0, 0, 0, ist),
+			},
+		},
+		{
+			name: "Weekly on Monday and Thursday",
+			rule: "FREQ=WEEKLY;BYDAY=TH,MO",
+			from: dtstart,
+			to:   time.Date(2025, 8, 15, 0, 0, 0, 0, ist),
+			expected: []time.Time{
+				time.Date(2025, 8, 4, 10, 0, 0, 0, ist),
+				time.Date(2025, 8, 7, 10, 0, 0, 0, ist),
+				time.Date(2025, 8, 11, 10, 0, 0, 0, ist),
+				time.Date(2025, 8, 14, 10, 0, 0, 0, ist),
+			},
+		},
+		{
+			name: "Fortnightly until a date",
+			rule: "FREQ=WEEKLY;INTERVAL=2;UNTIL=20250901T000000Z",
+			from: dtstart,
+			to:   time.Date(2025, 12, 31, 0, 0, 0, 0, ist),
+			expected: []time.Time{
+				time.Date(2025, 8, 4, 10, 0, 0, 0, ist),
+				time.Date(2025, 8, 18, 10, 0, 0, 0, ist),
+			},
+		},
+		{
+			name: "Occurrence already in progress at window start",
+			rule: "FREQ=DAILY",
+			from: time.Date(2025, 8, 5, 10, 30, 0, 0, ist),
+			to:   time.Date(2025, 8, 5, 12, 0, 0, 0, ist),
+			expected: []time.Time{
+				time.Date(2025, 8, 5, 10, 0, 0, 0, ist),
+			},
+		},
+		{
+			name: "Monthly skips short months",
+			rule: "FREQ=MONTHLY;COUNT=3",
+			from: time.Date(2025, 1, 1, 0, 0, 0, 0, ist),
+			to:   time.Date(2025, 12, 31, 0, 0, 0, 0, ist),
+			// dtstart is overridden below to the 31st
+			expected: []time.Time{
+				time.Date(2025, 1, 31, 10, 0, 0, 0, ist),
+				time.Date(2025, 3, 31, 10, 0, 0, 0, ist),
+				time.Date(2025, 5, 31, 10, 0, 0, 0, ist),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule, time.UTC)
+			if err != nil {
+				t.Fatalf("Failed to parse rule: %v", err)
+			}
+			start := dtstart
+			if rule.Freq == Monthly {
+				start = time.Date(2025, 1, 31, 10, 0, 0, 0, ist)
+			}
+			got := rule.Between(start, hour, tt.from, tt.to)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %d occurrences, got %d: %v", len(tt.expected), len(got), got)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.expected[i]) {
+					t.Errorf("Occurrence %d: expected %v, got %v", i, tt.expected[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLastAndCountBefore(t *testing.T) {
+	dtstart := time.Date(2025, 8, 4, 10, 0, 0, 0, getISTTimezone())
+
+	rule, _ := Parse("FREQ=DAILY;COUNT=10", time.UTC)
+	last, ok := rule.Last(dtstart)
+	if !ok {
+		t.Fatal("Expected bounded rule to have a last occurrence")
+	}
+	if !last.Equal(dtstart.AddDate(0, 0, 9)) {
+		t.Errorf("Expected last occurrence on day 10, got %v", last)
+	}
+	if n := rule.CountBefore(dtstart, dtstart.AddDate(0, 0, 3)); n != 3 {
+		t.Errorf("Expected 3 occurrences before day 4, got %d", n)
+	}
+	if !rule.Includes(dtstart, dtstart.AddDate(0, 0, 5)) {
+		t.Error("Expected day 6 to be an occurrence")
+	}
+	if rule.Includes(dtstart, dtstart.Add(time.Hour)) {
+		t.Error("Did not expect an occurrence an hour after the start")
+	}
+
+	// A start the rule does not match is not an occurrence
+	mondays, _ := Parse("FREQ=WEEKLY;BYDAY=MO", time.UTC)
+	tuesday := dtstart.AddDate(0, 0, 1)
+	if mondays.Includes(tuesday, tuesday) {
+		t.Error("Did not expect a Tuesday start to be a Monday occurrence")
+	}
+	var first time.Time
+	mondays.Each(tuesday, func(occ time.Time) bool {
+		first = occ
+		return false
+	})
+	if !first.Equal(dtstart.AddDate(0, 0, 7)) {
+		t.Errorf("Expected the first occurrence the following Monday, got %v", first)
+	}
+
+	unbounded, _ := Parse("FREQ=WEEKLY", time.UTC)
+	if _, ok := unbounded.Last(dtstart); ok {
+		t.Error("Expected unbounded rule to have no last occurrence")
+	}
+}
+
+func TestUntilInStartZone(t *testing.T) {
+	ist := getISTTimezone()
+	dtstart := time.Date(2025, 8, 4, 10, 0, 0, 0, ist)
+
+	// Floating and date-only UNTIL values are in dtstart's zone: 09:30 IST
+	// falls before the 10:00 occurrence on 8 August, a whole date does not
+	for value, expected := range map[string]time.Time{
+		"FREQ=DAILY;UNTIL=20250808T093000": time.Date(2025, 8, 7, 10, 0, 0, 0, ist),
+		"FREQ=DAILY;UNTIL=20250808":        time.Date(2025, 8, 8, 10, 0, 0, 0, ist),
+	} {
+		rule, err := Parse(value, ist)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", value, err)
+		}
+		if last, ok := rule.Last(dtstart); !ok || !last.Equal(expected) {
+			t.Errorf("%s: expected the last occurrence at %v, got %v", value, expected, last)
+		}
+	}
+
+	// An UNTIL in UTC is an exact instant wherever the series is
+	rule, _ := Parse("FREQ=DAILY;UNTIL=20250808T040000Z", ist)
+	if last, _ := rule.Last(dtstart); !last.Equal(time.Date(2025, 8, 7, 10, 0, 0, 0, ist)) {
+		t.Errorf("Expected the last occurrence on 7 August, got %v", last)
+	}
+}
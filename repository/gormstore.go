@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"errors"
+	"smart-scheduler/model"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GormStore is the Store backed by a gorm database handle. Times in queries
+// are passed in UTC so that they compare correctly on SQLite, which stores
+// them as text.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Transaction(fn func(tx Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&GormStore{db: tx})
+	})
+}
+
+// LockParticipants takes a transaction-scoped advisory lock per participant
+// on Postgres, in sorted order so concurrent bookings cannot deadlock. SQLite
+// needs no lock: it is opened with a single connection, so transactions
+// already run one at a time.
+func (s *GormStore) LockParticipants(userIds []string) error {
+	if s.db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	sorted := append([]string{}, userIds...)
+	sort.Strings(sorted)
+	for _, userId := range sorted {
+		if err := s.db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "participant:"+userId).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LockResources locks resources as LockParticipants locks participants.
+func (s *GormStore) LockResources(resourceIds []string) error {
+	if s.db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	sorted := append([]string{}, resourceIds...)
+	sort.Strings(sorted)
+	for _, resourceId := range sorted {
+		if err := s.db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "resource:"+resourceId).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GormStore) CreateUser(user *model.User) error {
+	return s.duplicate(s.db.Create(user).Error)
+}
+
+func (s *GormStore) FindUser(userCode string) (*model.User, error) {
+	var user model.User
+	err := s.db.Preload("WorkingHours").Where("user_code = ?", userCode).First(&user).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (s *GormStore) FindUsers(userCodes []string) ([]model.User, error) {
+	if len(userCodes) == 0 {
+		return nil, nil
+	}
+	var users []model.User
+	err := s.db.Preload("WorkingHours").Where("user_code IN ?", userCodes).Find(&users).Error
+	return users, err
+}
+
+func (s *GormStore) FindUserByEmail(email string) (*model.User, error) {
+	var user model.User
+	err := s.db.Preload("WorkingHours").Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (s *GormStore) UpdateUserProfile(userCode, timezone, email string, hours []model.WorkingHours) error {
+	updates := map[string]any{"timezone": timezone, "email": email}
+	if err := s.db.Model(&model.User{}).Where("user_code = ?", userCode).Updates(updates).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("user_id = ?", userCode).Delete(&model.WorkingHours{}).Error; err != nil {
+		return err
+	}
+	if len(hours) == 0 {
+		return nil
+	}
+	return s.db.Create(&hours).Error
+}
+
+func (s *GormStore) ListUsers(search string, offset, limit int) ([]model.User, int64, error) {
+	matching := func() *gorm.DB {
+		query := s.db.Model(&model.User{})
+		if search != "" {
+			query = query.Where(`LOWER(name) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(search))+"%")
+		}
+		return query
+	}
+	var total int64
+	if err := matching().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []model.User
+	err := matching().Preload("WorkingHours").Order("user_code").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// likeEscaper escapes the LIKE wildcards in a search term.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (s *GormStore) UpdateUser(user *model.User) error {
+	updates := map[string]any{"name": user.Name, "email": user.Email, "timezone": user.Timezone, "organization": user.Organization}
+	result := s.db.Model(&model.User{}).Where("user_code = ?", user.UserCode).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *GormStore) DeleteUser(userCode string) error {
+	for _, table := range []any{&model.WorkingHours{}, &model.Event{}, &model.Hold{}, &model.MeetingAttendee{}} {
+		if err := s.db.Where("user_id = ?", userCode).Delete(table).Error; err != nil {
+			return err
+		}
+	}
+	orphaned := s.db.Model(&model.MeetingAttendee{}).Select("meeting_id")
+	if err := s.db.Where("meeting_id NOT IN (?)", orphaned).Delete(&model.MeetingResource{}).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("id NOT IN (?)", orphaned).Delete(&model.Meeting{}).Error; err != nil {
+		return err
+	}
+	for _, table := range []any{&model.Meeting{}, &model.Hold{}} {
+		if err := s.db.Model(table).Where("organizer_id = ?", userCode).Update("organizer_id", "").Error; err != nil {
+			return err
+		}
+	}
+	result := s.db.Where("user_code = ?", userCode).Delete(&model.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *GormStore) CreateEvent(event *model.Event) error {
+	return s.db.Create(event).Error
+}
+
+func (s *GormStore) SaveEvent(event *model.Event) error {
+	return s.db.Save(event).Error
+}
+
+func (s *GormStore) DeleteEvent(event *model.Event) error {
+	return s.db.Delete(event).Error
+}
+
+func (s *GormStore) FindEvent(id uint) (*model.Event, error) {
+	var event model.Event
+	if err := s.db.First(&event, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &event, nil
+}
+
+func (s *GormStore) UserEvents(userId string) ([]model.Event, error) {
+	var events []model.Event
+	err := s.db.Where("user_id = ?", userId).Find(&events).Error
+	return events, err
+}
+
+func (s *GormStore) ImportedEvents(userId, source string) ([]model.Event, error) {
+	var events []model.Event
+	err := s.db.Where("user_id = ? AND source = ?", userId, source).Find(&events).Error
+	return events, err
+}
+
+func (s *GormStore) OneOffEventsInRange(userId string, start, end time.Time) ([]model.Event, error) {
+	var events []model.Event
+	// An event overlaps if: event_start < range_end AND event_end > range_start
+	err := s.db.Where("user_id = ? AND (rrule IS NULL OR rrule = '') AND recurring_event_id IS NULL AND start_time < ? AND end_time > ?", userId, end.UTC(), start.UTC()).
+		Find(&events).Error
+	return events, err
+}
+
+func (s *GormStore) SeriesInRange(userId string, start, end time.Time) ([]model.Event, error) {
+	var masters []model.Event
+	err := s.db.Where("user_id = ? AND rrule <> '' AND start_time < ? AND (series_end IS NULL OR series_end > ?)", userId, end.UTC(), start.UTC()).
+		Find(&masters).Error
+	return masters, err
+}
+
+func (s *GormStore) Overrides(seriesIds []uint) ([]model.Event, error) {
+	if len(seriesIds) == 0 {
+		return nil, nil
+	}
+	var overrides []model.Event
+	err := s.db.Where("recurring_event_id IN ?", seriesIds).Find(&overrides).Error
+	return overrides, err
+}
+
+func (s *GormStore) FindOverride(seriesId uint, recurrenceId time.Time) (*model.Event, error) {
+	var override model.Event
+	err := s.db.Where("recurring_event_id = ? AND recurrence_id = ?", seriesId, recurrenceId.UTC()).First(&override).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &override, nil
+}
+
+func (s *GormStore) DeleteOverrides(seriesId uint, from time.Time) error {
+	return s.db.Where("recurring_event_id = ? AND recurrence_id >= ?", seriesId, from.UTC()).Delete(&model.Event{}).Error
+}
+
+func (s *GormStore) MoveOverrides(fromSeriesId, toSeriesId uint, from time.Time) error {
+	return s.db.Model(&model.Event{}).
+		Where("recurring_event_id = ? AND recurrence_id >= ?", fromSeriesId, from.UTC()).
+		Update("recurring_event_id", toSeriesId).Error
+}
+
+func (s *GormStore) DeleteEventsByCode(eventCode string) (int64, error) {
+	result := s.db.Where("event_code = ?", eventCode).Delete(&model.Event{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *GormStore) DeleteLegacyMeetingEvents(meetingCode string) (int64, error) {
+	result := s.db.Where("event_code = ? || '-' || user_id", meetingCode).Delete(&model.Event{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *GormStore) DeleteEventsByCodePrefix(prefix string) (int64, error) {
+	result := s.db.Where(`event_code LIKE ? ESCAPE '\'`, likeEscaper.Replace(prefix)+"%").Delete(&model.Event{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *GormStore) CreateMeeting(meeting *model.Meeting) error {
+	return s.db.Create(meeting).Error
+}
+
+func (s *GormStore) FindMeeting(meetingCode string) (*model.Meeting, error) {
+	var meeting model.Meeting
+	err := s.db.Preload("Attendees").Preload("Resources").Where("meeting_code = ?", meetingCode).First(&meeting).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &meeting, nil
+}
+
+func (s *GormStore) SaveMeeting(meeting *model.Meeting) error {
+	return s.db.Omit("Attendees", "Resources").Save(meeting).Error
+}
+
+func (s *GormStore) DeleteMeeting(meeting *model.Meeting) error {
+	for _, table := range []any{&model.MeetingAttendee{}, &model.MeetingResource{}} {
+		if err := s.db.Where("meeting_id = ?", meeting.ID).Delete(table).Error; err != nil {
+			return err
+		}
+	}
+	return s.db.Delete(meeting).Error
+}
+
+func (s *GormStore) ResetRSVPs(meetingId uint) error {
+	return s.db.Model(&model.MeetingAttendee{}).Where("meeting_id = ?", meetingId).Update("status", model.RSVPNeedsAction).Error
+}
+
+func (s *GormStore) SetRSVP(meetingId uint, userId, status string) error {
+	result := s.db.Model(&model.MeetingAttendee{}).Where("meeting_id = ? AND user_id = ?", meetingId, userId).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *GormStore) MeetingsAttendedBy(userId string) ([]model.Meeting, error) {
+	var meetings []model.Meeting
+	err := s.attendedBy(userId).Find(&meetings).Error
+	return meetings, err
+}
+
+func (s *GormStore) MeetingsAttendedInRange(userId string, start, end time.Time) ([]model.Meeting, error) {
+	var meetings []model.Meeting
+	err := s.attendedBy(userId).
+		Where("meetings.start_time < ?", end.UTC()).
+		Where("(((meetings.rrule IS NULL OR meetings.rrule = '') AND meetings.end_time > ?) OR (meetings.rrule <> '' AND (meetings.series_end IS NULL OR meetings.series_end > ?)))", start.UTC(), start.UTC()).
+		Find(&meetings).Error
+	return meetings, err
+}
+
+func (s *GormStore) attendedBy(userId string) *gorm.DB {
+	return s.db.Preload("Attendees").Preload("Resources").
+		Joins("JOIN meeting_attendees ON meeting_attendees.meeting_id = meetings.id").
+		Where("meeting_attendees.user_id = ?", userId)
+}
+
+func (s *GormStore) CreateResource(resource *model.Resource) error {
+	return s.duplicate(s.db.Create(resource).Error)
+}
+
+func (s *GormStore) FindResource(resourceCode string) (*model.Resource, error) {
+	var resource model.Resource
+	if err := s.db.Where("resource_code = ?", resourceCode).First(&resource).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &resource, nil
+}
+
+func (s *GormStore) ListResources() ([]model.Resource, error) {
+	var resources []model.Resource
+	err := s.db.Order("resource_code").Find(&resources).Error
+	return resources, err
+}
+
+func (s *GormStore) DeleteResource(resourceCode string) error {
+	if err := s.db.Where("resource_id = ?", resourceCode).Delete(&model.MeetingResource{}).Error; err != nil {
+		return err
+	}
+	result := s.db.Where("resource_code = ?", resourceCode).Delete(&model.Resource{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *GormStore) MeetingsBookingResourceInRange(resourceCode string, start, end time.Time) ([]model.Meeting, error) {
+	var meetings []model.Meeting
+	err := s.db.Preload("Attendees").Preload("Resources").
+		Joins("JOIN meeting_resources ON meeting_resources.meeting_id = meetings.id").
+		Where("meeting_resources.resource_id = ?", resourceCode).
+		Where("meetings.start_time < ?", end.UTC()).
+		Where("(((meetings.rrule IS NULL OR meetings.rrule = '') AND meetings.end_time > ?) OR (meetings.rrule <> '' AND (meetings.series_end IS NULL OR meetings.series_end > ?)))", start.UTC(), start.UTC()).
+		Find(&meetings).Error
+	return meetings, err
+}
+
+func (s *GormStore) CreateHold(hold *model.Hold) error {
+	return s.db.Create(hold).Error
+}
+
+func (s *GormStore) ActiveHolds(userId string, start, end, now time.Time) ([]model.Hold, error) {
+	var holds []model.Hold
+	err := s.db.Where("user_id = ? AND start_time < ? AND end_time > ? AND expires_at > ?", userId, end.UTC(), start.UTC(), now.UTC()).Find(&holds).Error
+	return holds, err
+}
+
+func (s *GormStore) ActiveHoldsByCode(holdCode string, now time.Time) ([]model.Hold, error) {
+	var holds []model.Hold
+	err := s.db.Where("hold_code = ? AND expires_at > ?", holdCode, now.UTC()).Find(&holds).Error
+	return holds, err
+}
+
+func (s *GormStore) DeleteHolds(holdCode string) (int64, error) {
+	result := s.db.Where("hold_code = ?", holdCode).Delete(&model.Hold{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *GormStore) DeleteExpiredHolds(now time.Time) (int64, error) {
+	result := s.db.Where("expires_at <= ?", now.UTC()).Delete(&model.Hold{})
+	return result.RowsAffected, result.Error
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// duplicate reports a unique constraint violation, in whichever form the
+// database driver gives it, as ErrDuplicate.
+func (s *GormStore) duplicate(err error) error {
+	if translator, ok := s.db.Dialector.(gorm.ErrorTranslator); ok && err != nil {
+		if errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+			return ErrDuplicate
+		}
+	}
+	return err
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	database "smart-scheduler/db"
+	"testing"
+)
+
+func TestGormStoreQueriesOnSQLite(t *testing.T) {
+	conn, err := database.Open("sqlite", ":memory:", "")
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	testStoreQueries(t, NewGormStore(conn))
+}
@@ -0,0 +1,686 @@
+package repository
+
+import (
+	"fmt"
+	"smart-scheduler/model"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store kept entirely in process, for tests and for running
+// the server without a database. Transactions hold a store-wide lock and
+// roll back by restoring a snapshot.
+type MemoryStore struct {
+	mu   *sync.Mutex
+	inTx bool
+	data *memoryData
+}
+
+type memoryData struct {
+	nextID    uint
+	users     []model.User // working hours are kept on the user
+	events    []model.Event
+	meetings  []model.Meeting // attendees and resource bookings are kept on the meeting
+	holds     []model.Hold
+	resources []model.Resource
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{mu: &sync.Mutex{}, data: &memoryData{}}
+}
+
+// lock takes the store-wide lock unless the store is already inside a
+// transaction, which holds it.
+func (s *MemoryStore) lock() func() {
+	if s.inTx {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *MemoryStore) Transaction(fn func(tx Store) error) error {
+	defer s.lock()()
+	snapshot := s.data.clone()
+	if err := fn(&MemoryStore{mu: s.mu, inTx: true, data: s.data}); err != nil {
+		*s.data = *snapshot
+		return err
+	}
+	return nil
+}
+
+// LockParticipants is a no-op: transactions already hold the store lock.
+func (s *MemoryStore) LockParticipants(userIds []string) error {
+	return nil
+}
+
+// LockResources is a no-op for the same reason.
+func (s *MemoryStore) LockResources(resourceIds []string) error {
+	return nil
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := &memoryData{
+		nextID:    d.nextID,
+		events:    append([]model.Event{}, d.events...),
+		holds:     append([]model.Hold{}, d.holds...),
+		resources: append([]model.Resource{}, d.resources...),
+	}
+	for _, u := range d.users {
+		c.users = append(c.users, copyUser(u))
+	}
+	for _, m := range d.meetings {
+		c.meetings = append(c.meetings, copyMeeting(m))
+	}
+	return c
+}
+
+func (d *memoryData) newID() uint {
+	d.nextID++
+	return d.nextID
+}
+
+func copyUser(u model.User) model.User {
+	u.WorkingHours = append([]model.WorkingHours(nil), u.WorkingHours...)
+	return u
+}
+
+func copyMeeting(m model.Meeting) model.Meeting {
+	m.Attendees = append([]model.MeetingAttendee(nil), m.Attendees...)
+	m.Resources = append([]model.MeetingResource(nil), m.Resources...)
+	return m
+}
+
+func overlaps(start, end, rangeStart, rangeEnd time.Time) bool {
+	return start.Before(rangeEnd) && end.After(rangeStart)
+}
+
+// seriesMayOverlap mirrors the SQL used for series: the first occurrence
+// starts before the range ends and the series does not end before it starts.
+func seriesMayOverlap(start time.Time, seriesEnd *time.Time, rangeStart, rangeEnd time.Time) bool {
+	return start.Before(rangeEnd) && (seriesEnd == nil || seriesEnd.After(rangeStart))
+}
+
+func (s *MemoryStore) CreateUser(user *model.User) error {
+	defer s.lock()()
+	for _, u := range s.data.users {
+		if u.UserCode == user.UserCode {
+			return fmt.Errorf("%w: user %s", ErrDuplicate, user.UserCode)
+		}
+	}
+	user.ID = s.data.newID()
+	for i := range user.WorkingHours {
+		user.WorkingHours[i].ID = s.data.newID()
+		user.WorkingHours[i].UserID = user.UserCode
+	}
+	s.data.users = append(s.data.users, copyUser(*user))
+	return nil
+}
+
+func (s *MemoryStore) FindUser(userCode string) (*model.User, error) {
+	defer s.lock()()
+	for _, u := range s.data.users {
+		if u.UserCode == userCode {
+			user := copyUser(u)
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) FindUsers(userCodes []string) ([]model.User, error) {
+	defer s.lock()()
+	var users []model.User
+	for _, u := range s.data.users {
+		for _, code := range userCodes {
+			if u.UserCode == code {
+				users = append(users, copyUser(u))
+				break
+			}
+		}
+	}
+	return users, nil
+}
+
+func (s *MemoryStore) FindUserByEmail(email string) (*model.User, error) {
+	defer s.lock()()
+	for _, u := range s.data.users {
+		if u.Email != "" && strings.EqualFold(u.Email, email) {
+			user := copyUser(u)
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) UpdateUserProfile(userCode, timezone, email string, hours []model.WorkingHours) error {
+	defer s.lock()()
+	for i := range s.data.users {
+		if s.data.users[i].UserCode != userCode {
+			continue
+		}
+		s.data.users[i].Timezone = timezone
+		s.data.users[i].Email = email
+		s.data.users[i].WorkingHours = nil
+		for j := range hours {
+			hours[j].ID = s.data.newID()
+			s.data.users[i].WorkingHours = append(s.data.users[i].WorkingHours, hours[j])
+		}
+		return nil
+	}
+	return nil
+}
+
+func (s *MemoryStore) ListUsers(search string, offset, limit int) ([]model.User, int64, error) {
+	defer s.lock()()
+	var matched []model.User
+	for _, u := range s.data.users {
+		if strings.Contains(strings.ToLower(u.Name), strings.ToLower(search)) {
+			matched = append(matched, copyUser(u))
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].UserCode < matched[j].UserCode })
+	total := int64(len(matched))
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	matched = matched[offset:]
+	if limit < len(matched) {
+		matched = matched[:limit]
+	}
+	return matched, total, nil
+}
+
+func (s *MemoryStore) UpdateUser(user *model.User) error {
+	defer s.lock()()
+	for i := range s.data.users {
+		if s.data.users[i].UserCode == user.UserCode {
+			s.data.users[i].Name = user.Name
+			s.data.users[i].Email = user.Email
+			s.data.users[i].Timezone = user.Timezone
+			s.data.users[i].Organization = user.Organization
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryStore) DeleteUser(userCode string) error {
+	defer s.lock()()
+	found := false
+	users := s.data.users[:0]
+	for _, u := range s.data.users {
+		if u.UserCode == userCode {
+			found = true
+			continue
+		}
+		users = append(users, u)
+	}
+	if !found {
+		return ErrNotFound
+	}
+	s.data.users = users
+
+	events := s.data.events[:0]
+	for _, e := range s.data.events {
+		if e.UserID != userCode {
+			events = append(events, e)
+		}
+	}
+	s.data.events = events
+
+	holds := s.data.holds[:0]
+	for _, h := range s.data.holds {
+		if h.OrganizerID == userCode {
+			h.OrganizerID = ""
+		}
+		if h.UserID != userCode {
+			holds = append(holds, h)
+		}
+	}
+	s.data.holds = holds
+
+	meetings := s.data.meetings[:0]
+	for _, m := range s.data.meetings {
+		attendees := m.Attendees[:0]
+		for _, a := range m.Attendees {
+			if a.UserID != userCode {
+				attendees = append(attendees, a)
+			}
+		}
+		m.Attendees = attendees
+		if m.OrganizerID == userCode {
+			m.OrganizerID = ""
+		}
+		if len(m.Attendees) > 0 {
+			meetings = append(meetings, m)
+		}
+	}
+	s.data.meetings = meetings
+	return nil
+}
+
+func (s *MemoryStore) CreateEvent(event *model.Event) error {
+	defer s.lock()()
+	for _, e := range s.data.events {
+		if e.EventCode == event.EventCode {
+			return fmt.Errorf("event %s already exists", event.EventCode)
+		}
+	}
+	if err := event.BeforeSave(nil); err != nil {
+		return err
+	}
+	event.ID = s.data.newID()
+	s.data.events = append(s.data.events, *event)
+	return nil
+}
+
+func (s *MemoryStore) SaveEvent(event *model.Event) error {
+	if event.ID == 0 {
+		return s.CreateEvent(event)
+	}
+	defer s.lock()()
+	if err := event.BeforeSave(nil); err != nil {
+		return err
+	}
+	for i := range s.data.events {
+		if s.data.events[i].ID == event.ID {
+			s.data.events[i] = *event
+			return nil
+		}
+	}
+	s.data.events = append(s.data.events, *event)
+	return nil
+}
+
+func (s *MemoryStore) DeleteEvent(event *model.Event) error {
+	defer s.lock()()
+	s.deleteEvents(func(e model.Event) bool { return e.ID == event.ID })
+	return nil
+}
+
+func (s *MemoryStore) deleteEvents(match func(model.Event) bool) int64 {
+	var deleted int64
+	kept := s.data.events[:0]
+	for _, e := range s.data.events {
+		if match(e) {
+			deleted++
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	s.data.events = kept
+	return deleted
+}
+
+func (s *MemoryStore) findEvents(match func(model.Event) bool) []model.Event {
+	var events []model.Event
+	for _, e := range s.data.events {
+		if match(e) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (s *MemoryStore) FindEvent(id uint) (*model.Event, error) {
+	defer s.lock()()
+	for _, e := range s.data.events {
+		if e.ID == id {
+			event := e
+			return &event, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) UserEvents(userId string) ([]model.Event, error) {
+	defer s.lock()()
+	return s.findEvents(func(e model.Event) bool { return e.UserID == userId }), nil
+}
+
+func (s *MemoryStore) ImportedEvents(userId, source string) ([]model.Event, error) {
+	defer s.lock()()
+	return s.findEvents(func(e model.Event) bool { return e.UserID == userId && e.Source == source }), nil
+}
+
+func (s *MemoryStore) OneOffEventsInRange(userId string, start, end time.Time) ([]model.Event, error) {
+	defer s.lock()()
+	return s.findEvents(func(e model.Event) bool {
+		return e.UserID == userId && e.RRule == "" && e.RecurringEventID == nil && overlaps(e.StartTime, e.EndTime, start, end)
+	}), nil
+}
+
+func (s *MemoryStore) SeriesInRange(userId string, start, end time.Time) ([]model.Event, error) {
+	defer s.lock()()
+	return s.findEvents(func(e model.Event) bool {
+		return e.UserID == userId && e.RRule != "" && seriesMayOverlap(e.StartTime, e.SeriesEnd, start, end)
+	}), nil
+}
+
+func (s *MemoryStore) Overrides(seriesIds []uint) ([]model.Event, error) {
+	defer s.lock()()
+	return s.findEvents(func(e model.Event) bool {
+		if e.RecurringEventID == nil {
+			return false
+		}
+		for _, id := range seriesIds {
+			if *e.RecurringEventID == id {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (s *MemoryStore) FindOverride(seriesId uint, recurrenceId time.Time) (*model.Event, error) {
+	defer s.lock()()
+	found := s.findEvents(func(e model.Event) bool {
+		return isOverrideOf(e, seriesId) && e.RecurrenceID != nil && e.RecurrenceID.Equal(recurrenceId)
+	})
+	if len(found) == 0 {
+		return nil, ErrNotFound
+	}
+	return &found[0], nil
+}
+
+func (s *MemoryStore) DeleteOverrides(seriesId uint, from time.Time) error {
+	defer s.lock()()
+	s.deleteEvents(func(e model.Event) bool {
+		return isOverrideOf(e, seriesId) && e.RecurrenceID != nil && !e.RecurrenceID.Before(from)
+	})
+	return nil
+}
+
+func (s *MemoryStore) MoveOverrides(fromSeriesId, toSeriesId uint, from time.Time) error {
+	defer s.lock()()
+	for i, e := range s.data.events {
+		if isOverrideOf(e, fromSeriesId) && e.RecurrenceID != nil && !e.RecurrenceID.Before(from) {
+			seriesId := toSeriesId
+			s.data.events[i].RecurringEventID = &seriesId
+		}
+	}
+	return nil
+}
+
+func isOverrideOf(e model.Event, seriesId uint) bool {
+	return e.RecurringEventID != nil && *e.RecurringEventID == seriesId
+}
+
+func (s *MemoryStore) DeleteEventsByCode(eventCode string) (int64, error) {
+	defer s.lock()()
+	return s.deleteEvents(func(e model.Event) bool { return e.EventCode == eventCode }), nil
+}
+
+func (s *MemoryStore) DeleteEventsByCodePrefix(prefix string) (int64, error) {
+	defer s.lock()()
+	return s.deleteEvents(func(e model.Event) bool { return strings.HasPrefix(e.EventCode, prefix) }), nil
+}
+
+func (s *MemoryStore) DeleteLegacyMeetingEvents(meetingCode string) (int64, error) {
+	defer s.lock()()
+	return s.deleteEvents(func(e model.Event) bool { return e.EventCode == meetingCode+"-"+e.UserID }), nil
+}
+
+func (s *MemoryStore) CreateMeeting(meeting *model.Meeting) error {
+	defer s.lock()()
+	for _, m := range s.data.meetings {
+		if m.MeetingCode == meeting.MeetingCode {
+			return fmt.Errorf("meeting %s already exists", meeting.MeetingCode)
+		}
+	}
+	if err := meeting.BeforeSave(nil); err != nil {
+		return err
+	}
+	meeting.ID = s.data.newID()
+	for i := range meeting.Attendees {
+		meeting.Attendees[i].ID = s.data.newID()
+		meeting.Attendees[i].MeetingID = meeting.ID
+	}
+	for i := range meeting.Resources {
+		meeting.Resources[i].ID = s.data.newID()
+		meeting.Resources[i].MeetingID = meeting.ID
+	}
+	s.data.meetings = append(s.data.meetings, copyMeeting(*meeting))
+	return nil
+}
+
+func (s *MemoryStore) FindMeeting(meetingCode string) (*model.Meeting, error) {
+	defer s.lock()()
+	for _, m := range s.data.meetings {
+		if m.MeetingCode == meetingCode {
+			meeting := copyMeeting(m)
+			return &meeting, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) SaveMeeting(meeting *model.Meeting) error {
+	defer s.lock()()
+	if err := meeting.BeforeSave(nil); err != nil {
+		return err
+	}
+	for i := range s.data.meetings {
+		if s.data.meetings[i].ID == meeting.ID {
+			attendees, resources := s.data.meetings[i].Attendees, s.data.meetings[i].Resources
+			s.data.meetings[i] = copyMeeting(*meeting)
+			s.data.meetings[i].Attendees, s.data.meetings[i].Resources = attendees, resources
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryStore) DeleteMeeting(meeting *model.Meeting) error {
+	defer s.lock()()
+	kept := s.data.meetings[:0]
+	for _, m := range s.data.meetings {
+		if m.ID != meeting.ID {
+			kept = append(kept, m)
+		}
+	}
+	s.data.meetings = kept
+	return nil
+}
+
+func (s *MemoryStore) ResetRSVPs(meetingId uint) error {
+	defer s.lock()()
+	for i := range s.data.meetings {
+		if s.data.meetings[i].ID != meetingId {
+			continue
+		}
+		for j := range s.data.meetings[i].Attendees {
+			s.data.meetings[i].Attendees[j].Status = model.RSVPNeedsAction
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) SetRSVP(meetingId uint, userId, status string) error {
+	defer s.lock()()
+	for i := range s.data.meetings {
+		if s.data.meetings[i].ID != meetingId {
+			continue
+		}
+		for j := range s.data.meetings[i].Attendees {
+			if s.data.meetings[i].Attendees[j].UserID == userId {
+				s.data.meetings[i].Attendees[j].Status = status
+				return nil
+			}
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *MemoryStore) MeetingsAttendedBy(userId string) ([]model.Meeting, error) {
+	defer s.lock()()
+	return s.findMeetings(func(m model.Meeting) bool { return attends(m, userId) }), nil
+}
+
+func (s *MemoryStore) MeetingsAttendedInRange(userId string, start, end time.Time) ([]model.Meeting, error) {
+	defer s.lock()()
+	return s.findMeetings(func(m model.Meeting) bool {
+		if !attends(m, userId) {
+			return false
+		}
+		if m.RRule == "" {
+			return overlaps(m.StartTime, m.EndTime, start, end)
+		}
+		return seriesMayOverlap(m.StartTime, m.SeriesEnd, start, end)
+	}), nil
+}
+
+func (s *MemoryStore) findMeetings(match func(model.Meeting) bool) []model.Meeting {
+	var meetings []model.Meeting
+	for _, m := range s.data.meetings {
+		if match(m) {
+			meetings = append(meetings, copyMeeting(m))
+		}
+	}
+	return meetings
+}
+
+func attends(m model.Meeting, userId string) bool {
+	for _, a := range m.Attendees {
+		if a.UserID == userId {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) CreateResource(resource *model.Resource) error {
+	defer s.lock()()
+	for _, r := range s.data.resources {
+		if r.ResourceCode == resource.ResourceCode {
+			return fmt.Errorf("%w: resource %s", ErrDuplicate, resource.ResourceCode)
+		}
+	}
+	resource.ID = s.data.newID()
+	s.data.resources = append(s.data.resources, *resource)
+	return nil
+}
+
+func (s *MemoryStore) FindResource(resourceCode string) (*model.Resource, error) {
+	defer s.lock()()
+	for _, r := range s.data.resources {
+		if r.ResourceCode == resourceCode {
+			resource := r
+			return &resource, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) ListResources() ([]model.Resource, error) {
+	defer s.lock()()
+	resources := append([]model.Resource{}, s.data.resources...)
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ResourceCode < resources[j].ResourceCode })
+	return resources, nil
+}
+
+func (s *MemoryStore) DeleteResource(resourceCode string) error {
+	defer s.lock()()
+	found := false
+	resources := s.data.resources[:0]
+	for _, r := range s.data.resources {
+		if r.ResourceCode == resourceCode {
+			found = true
+			continue
+		}
+		resources = append(resources, r)
+	}
+	if !found {
+		return ErrNotFound
+	}
+	s.data.resources = resources
+
+	for i := range s.data.meetings {
+		booked := s.data.meetings[i].Resources[:0]
+		for _, r := range s.data.meetings[i].Resources {
+			if r.ResourceID != resourceCode {
+				booked = append(booked, r)
+			}
+		}
+		s.data.meetings[i].Resources = booked
+	}
+	return nil
+}
+
+func (s *MemoryStore) MeetingsBookingResourceInRange(resourceCode string, start, end time.Time) ([]model.Meeting, error) {
+	defer s.lock()()
+	return s.findMeetings(func(m model.Meeting) bool {
+		if !books(m, resourceCode) {
+			return false
+		}
+		if m.RRule == "" {
+			return overlaps(m.StartTime, m.EndTime, start, end)
+		}
+		return seriesMayOverlap(m.StartTime, m.SeriesEnd, start, end)
+	}), nil
+}
+
+func books(m model.Meeting, resourceCode string) bool {
+	for _, r := range m.Resources {
+		if r.ResourceID == resourceCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) CreateHold(hold *model.Hold) error {
+	defer s.lock()()
+	hold.ID = s.data.newID()
+	s.data.holds = append(s.data.holds, *hold)
+	return nil
+}
+
+func (s *MemoryStore) ActiveHolds(userId string, start, end, now time.Time) ([]model.Hold, error) {
+	defer s.lock()()
+	var holds []model.Hold
+	for _, h := range s.data.holds {
+		if h.UserID == userId && overlaps(h.StartTime, h.EndTime, start, end) && h.Active(now) {
+			holds = append(holds, h)
+		}
+	}
+	return holds, nil
+}
+
+func (s *MemoryStore) ActiveHoldsByCode(holdCode string, now time.Time) ([]model.Hold, error) {
+	defer s.lock()()
+	var holds []model.Hold
+	for _, h := range s.data.holds {
+		if h.HoldCode == holdCode && h.Active(now) {
+			holds = append(holds, h)
+		}
+	}
+	return holds, nil
+}
+
+func (s *MemoryStore) DeleteHolds(holdCode string) (int64, error) {
+	defer s.lock()()
+	return s.deleteHolds(func(h model.Hold) bool { return h.HoldCode == holdCode }), nil
+}
+
+func (s *MemoryStore) DeleteExpiredHolds(now time.Time) (int64, error) {
+	defer s.lock()()
+	return s.deleteHolds(func(h model.Hold) bool { return !h.Active(now) }), nil
+}
+
+func (s *MemoryStore) deleteHolds(match func(model.Hold) bool) int64 {
+	var deleted int64
+	kept := s.data.holds[:0]
+	for _, h := range s.data.holds {
+		if match(h) {
+			deleted++
+		} else {
+			kept = append(kept, h)
+		}
+	}
+	s.data.holds = kept
+	return deleted
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"errors"
+	"smart-scheduler/model"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTransactionRollback(t *testing.T) {
+	store := NewMemoryStore()
+	if err := CreateDummyData(store); err != nil {
+		t.Fatalf("Failed to create dummy data: %v", err)
+	}
+
+	failure := errors.New("boom")
+	err := store.Transaction(func(tx Store) error {
+		if _, err := tx.DeleteEventsByCodePrefix("event"); err != nil {
+			return err
+		}
+		if err := tx.UpdateUserProfile("user4", "UTC", "", nil); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the transaction's error, got %v", err)
+	}
+
+	events, _ := store.UserEvents("user1")
+	if len(events) != 2 {
+		t.Errorf("Expected deleted events to be restored, got %d", len(events))
+	}
+	user, err := store.FindUser("user4")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user.Timezone != "Asia/Kolkata" || len(user.WorkingHours) != 6 {
+		t.Errorf("Expected the profile to be restored, got %+v", user)
+	}
+}
+
+func TestMemoryStoreQueries(t *testing.T) {
+	testStoreQueries(t, NewMemoryStore())
+}
+
+// testStoreQueries checks the range and lookup queries every Store must
+// answer the same way.
+func testStoreQueries(t *testing.T, store Store) {
+	if err := CreateDummyData(store); err != nil {
+		t.Fatalf("Failed to create dummy data: %v", err)
+	}
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+	dayStart := time.Date(2025, 8, 9, 0, 0, 0, 0, ist)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	oneOff, _ := store.OneOffEventsInRange("user5", dayStart, dayEnd)
+	if len(oneOff) != 1 || oneOff[0].EventCode != "event8" {
+		t.Errorf("Expected only the training session, got %+v", oneOff)
+	}
+
+	// The same instants written in another zone must match the same rows:
+	// 16:00-17:00 IST is 10:30-11:30 UTC
+	utcStart := time.Date(2025, 8, 9, 10, 45, 0, 0, time.UTC)
+	if oneOff, _ := store.OneOffEventsInRange("user5", utcStart, utcStart.Add(time.Minute)); len(oneOff) != 1 {
+		t.Errorf("Expected the training session for a UTC range, got %d", len(oneOff))
+	}
+	if oneOff, _ := store.OneOffEventsInRange("user5", utcStart.Add(time.Hour), utcStart.Add(2*time.Hour)); len(oneOff) != 0 {
+		t.Errorf("Expected nothing after the training session, got %d", len(oneOff))
+	}
+
+	// The daily sync runs 1-30 August, so it ends long before September
+	if series, _ := store.SeriesInRange("user5", dayStart, dayEnd); len(series) != 1 {
+		t.Errorf("Expected the daily series, got %d", len(series))
+	}
+	if series, _ := store.SeriesInRange("user5", dayStart.AddDate(0, 1, 0), dayEnd.AddDate(0, 1, 0)); len(series) != 0 {
+		t.Errorf("Expected the finished series to be skipped, got %d", len(series))
+	}
+
+	if err := store.CreateEvent(&model.Event{EventCode: "event1", UserID: "user1"}); err == nil {
+		t.Error("Expected duplicate event codes to be rejected")
+	}
+	if _, err := store.FindEvent(9999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	meeting := &model.Meeting{
+		MeetingCode: "meeting-1",
+		StartTime:   dayStart.Add(10 * time.Hour),
+		EndTime:     dayStart.Add(11 * time.Hour),
+		Attendees:   []model.MeetingAttendee{{UserID: "user1", Status: model.RSVPAccepted}},
+	}
+	if err := store.CreateMeeting(meeting); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if meetings, _ := store.MeetingsAttendedInRange("user1", dayStart, dayEnd); len(meetings) != 1 {
+		t.Errorf("Expected the meeting in range, got %d", len(meetings))
+	}
+	if meetings, _ := store.MeetingsAttendedInRange("user2", dayStart, dayEnd); len(meetings) != 0 {
+		t.Errorf("Expected no meetings for a non-attendee, got %d", len(meetings))
+	}
+	if err := store.ResetRSVPs(meeting.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	found, _ := store.FindMeeting("meeting-1")
+	if found.Attendees[0].Status != model.RSVPNeedsAction {
+		t.Errorf("Expected the RSVP to be reset, got %s", found.Attendees[0].Status)
+	}
+	if err := store.SetRSVP(meeting.ID, "user1", model.RSVPDeclined); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if found, _ := store.FindMeeting("meeting-1"); found.Attendees[0].Status != model.RSVPDeclined {
+		t.Errorf("Expected the RSVP to be declined, got %s", found.Attendees[0].Status)
+	}
+	if err := store.SetRSVP(meeting.ID, "user2", model.RSVPAccepted); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a non-attendee, got %v", err)
+	}
+
+	if user, err := store.FindUserByEmail("Bob@Example.COM"); err != nil || user.UserCode != "user2" {
+		t.Errorf("Expected user2 by email, got %+v, %v", user, err)
+	}
+	if _, err := store.FindUserByEmail("nobody@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if resources, _ := store.ListResources(); len(resources) != 4 || resources[0].ResourceCode != "projector1" {
+		t.Errorf("Expected the dummy resources by code, got %+v", resources)
+	}
+	if err := store.CreateResource(&model.Resource{ResourceCode: "projector1", Name: "Another"}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for a taken resource code, got %v", err)
+	}
+	booked := &model.Meeting{
+		MeetingCode: "meeting-2",
+		StartTime:   dayStart.Add(14 * time.Hour),
+		EndTime:     dayStart.Add(15 * time.Hour),
+		Attendees:   []model.MeetingAttendee{{UserID: "user2", Status: model.RSVPAccepted}},
+		Resources:   []model.MeetingResource{{ResourceID: "room1"}},
+	}
+	if err := store.CreateMeeting(booked); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if meetings, _ := store.MeetingsBookingResourceInRange("room1", dayStart, dayEnd); len(meetings) != 1 || len(meetings[0].Resources) != 1 {
+		t.Errorf("Expected the meeting on room1's calendar, got %+v", meetings)
+	}
+	if meetings, _ := store.MeetingsBookingResourceInRange("room2", dayStart, dayEnd); len(meetings) != 0 {
+		t.Errorf("Expected nothing on room2's calendar, got %d", len(meetings))
+	}
+	if err := store.DeleteResource("room1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if found, _ := store.FindMeeting("meeting-2"); found == nil || len(found.Resources) != 0 {
+		t.Errorf("Expected the meeting to stay without its room, got %+v", found)
+	}
+	if err := store.DeleteResource("room1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	// Legacy meeting rows go by their exact "<code>-<user>" codes
+	for _, code := range []string{"meeting-20250101090000-user3", "meeting-20250101090000-user3-20250102T033000Z"} {
+		if err := store.CreateEvent(&model.Event{EventCode: code, UserID: "user3", StartTime: dayStart, EndTime: dayStart.Add(time.Hour)}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if deleted, err := store.DeleteLegacyMeetingEvents("meeting-20250101090000"); err != nil || deleted != 1 {
+		t.Errorf("Expected one legacy row deleted, got %d, %v", deleted, err)
+	}
+	if deleted, _ := store.DeleteEventsByCode("meeting-20250101090000-user3-20250102T033000Z"); deleted != 1 {
+		t.Error("Expected the row with a longer code to be left alone")
+	}
+
+	// "_" is a literal, not a LIKE wildcard
+	if users, total, _ := store.ListUsers("_", 0, 10); total != 0 || len(users) != 0 {
+		t.Errorf("Expected no names with an underscore, got %d", total)
+	}
+	if users, total, _ := store.ListUsers("SON", 1, 1); total != 2 || len(users) != 1 || users[0].UserCode != "user5" {
+		t.Errorf("Expected Eve Wilson as the second of the Johnson and Wilson, got %d %+v", total, users)
+	}
+
+	// Deleting user1 removes their events and the meeting only they attend
+	if err := store.DeleteUser("user1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := store.FindMeeting("meeting-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the meeting to go with its last attendee, got %v", err)
+	}
+	if events, _ := store.UserEvents("user1"); len(events) != 0 {
+		t.Errorf("Expected the user's events to go, got %d", len(events))
+	}
+	if err := store.DeleteUser("user1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	// Seeding again restores the dummy users without touching others
+	if err := store.CreateUser(&model.User{UserCode: "user10", Name: "Extra"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.CreateUser(&model.User{UserCode: "user10", Name: "Again"}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for a taken user code, got %v", err)
+	}
+	if err := store.UpdateUser(&model.User{UserCode: "user10", Name: "Extra", Organization: "research"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user, _ := store.FindUser("user10"); user == nil || user.Organization != "research" {
+		t.Errorf("Expected user10 in research, got %+v", user)
+	}
+	for _, code := range []string{"event10", "series1_x", "series1-20250805T040000Z"} {
+		if err := store.CreateEvent(&model.Event{EventCode: code, UserID: "user2", StartTime: dayStart, EndTime: dayStart.Add(time.Hour)}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := store.UpdateUser(&model.User{UserCode: "user2", Name: "Robert Smith", Timezone: "Europe/London"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := CreateDummyData(store); err != nil {
+		t.Fatalf("Failed to reseed dummy data: %v", err)
+	}
+	if _, total, _ := store.ListUsers("", 0, 10); total != 6 {
+		t.Errorf("Expected the five dummy users and user10, got %d", total)
+	}
+	if user, _ := store.FindUser("user2"); user == nil || user.Name != "Robert Smith" || user.Timezone != "Europe/London" {
+		t.Errorf("Expected reseeding to keep the edits to user2, got %+v", user)
+	}
+	codes := map[string]bool{}
+	events, _ := store.UserEvents("user2")
+	for _, e := range events {
+		codes[e.EventCode] = true
+	}
+	if !codes["event10"] || !codes["series1_x"] || codes["series1-20250805T040000Z"] {
+		t.Errorf("Expected only the series override to be cleared, got %v", codes)
+	}
+}
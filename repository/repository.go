@@ -1,18 +1,45 @@
 package repository
 
 import (
+	"errors"
 	"smart-scheduler/model"
 	"time"
-
-	"gorm.io/gorm"
 )
 
 // Request/Response types
 type ScheduleRequest struct {
 	Title           string   `json:"title"`
-	ParticipantIds  []string `json:"userIDs"`
+	OrganizerID     string   `json:"organizerId"` // defaults to the first participant
+	Description     string   `json:"description"`
+	Location        string   `json:"location"`
+	ParticipantIds  []string `json:"userIDs"`         // required attendees
+	OptionalIds     []string `json:"optionalUserIDs"` // invited but not required
+	MinQuorum       int      `json:"minQuorum"`       // minimum attendees, required plus optional, who must be free
 	DurationMinutes int      `json:"durationMinutes"`
-	TimeRange       struct {
+	HoldMinutes     int      `json:"holdMinutes"` // place tentative holds instead of booking when > 0
+	Recurrence      string   `json:"recurrence"`  // RRULE such as "FREQ=WEEKLY;COUNT=8"
+
+	// Overrides the organization's default scoring rules for this request
+	Scoring *ScoringOptions `json:"scoring,omitempty"`
+
+	// Spacing of candidate start times, overriding the server's: a step of
+	// 5, 10, 15, 30 or 60 minutes, and whether starts are aligned to
+	// multiples of the step past the hour in the organizer's time zone
+	StepMinutes  int   `json:"stepMinutes,omitempty"`
+	AlignToClock *bool `json:"alignToClock,omitempty"`
+
+	// Meetings a participant declined do not count as their busy time
+	IgnoreDeclined bool `json:"ignoreDeclined"`
+
+	// Rooms or equipment that must all be free, by code
+	ResourceIds []string `json:"resourceIDs"`
+	// Book any one resource matching these needs, such as a room for eight
+	// with video
+	Resource *ResourceRequirement `json:"resource,omitempty"`
+
+	// Set when rescheduling so the meeting's current slot is not busy time
+	ExcludeMeetingID uint `json:"-"`
+	TimeRange        struct {
 		Start string `json:"start"`
 		End   string `json:"end"`
 	} `json:"timeRange"`
@@ -21,9 +48,246 @@ type ScheduleRequest struct {
 type ScheduledMeetingResponse struct {
 	MeetingID      string   `json:"meetingId"`
 	Title          string   `json:"title"`
+	OrganizerID    string   `json:"organizerId,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Location       string   `json:"location,omitempty"`
 	ParticipantIds []string `json:"participantIds"`
+	OptionalIds    []string `json:"optionalIds,omitempty"`
+	UnavailableIds []string `json:"unavailableIds,omitempty"` // optional attendees who cannot make it
+	ResourceIds    []string `json:"resourceIds,omitempty"`    // booked rooms and equipment
 	StartTime      string   `json:"startTime"`
 	EndTime        string   `json:"endTime"`
+	Status         string   `json:"status,omitempty"`
+	HoldID         string   `json:"holdId,omitempty"`
+	HoldExpiresAt  string   `json:"holdExpiresAt,omitempty"`
+	Recurrence     string   `json:"recurrence,omitempty"`
+	// Occurrences of a recurring meeting that still collide
+	Conflicts []OccurrenceConflict `json:"conflicts,omitempty"`
+}
+
+// ScoringOptions selects and tunes the rules used to rank candidate slots.
+// Zero values keep the profile's defaults. The server's defaults can be
+// tuned for each organization, and apply to meetings organized by its
+// members.
+type ScoringOptions struct {
+	Profile string         `json:"profile,omitempty"` // "default", "balanced" or "focus"
+	Weights map[string]int `json:"weights,omitempty"` // rule name to weight, 0 turns a rule off
+
+	BufferMinutes   int    `json:"bufferMinutes,omitempty"`   // wanted gap around meetings
+	MinGapMinutes   int    `json:"minGapMinutes,omitempty"`   // shorter free gaps count as fragmentation
+	MaxBackToBack   int    `json:"maxBackToBack,omitempty"`   // meetings in a row before a penalty
+	LunchStart      string `json:"lunchStart,omitempty"`      // "HH:MM" in the participant's zone
+	LunchEnd        string `json:"lunchEnd,omitempty"`        // "HH:MM" in the participant's zone
+	MaxDailyMinutes int    `json:"maxDailyMinutes,omitempty"` // busy minutes per day before a penalty
+}
+
+// ResourceRequirement describes the resource a meeting needs. The smallest
+// free match is booked.
+type ResourceRequirement struct {
+	Type        string   `json:"type"`        // defaults to "room"
+	MinCapacity int      `json:"minCapacity"` // seats needed
+	Features    []string `json:"features"`    // every one must be offered, such as "video"
+	Location    string   `json:"location"`    // exact location, when set
+}
+
+// ResourceDetails is a room or piece of equipment as created and returned by
+// the resources API.
+type ResourceDetails struct {
+	ResourceCode string   `json:"resourceCode"` // letters, digits, "-" and "_"
+	Name         string   `json:"name"`
+	Type         string   `json:"type"` // defaults to "room"
+	Capacity     int      `json:"capacity"`
+	Location     string   `json:"location,omitempty"`
+	Features     []string `json:"features"`
+}
+
+type OccurrenceConflict struct {
+	StartTime      string   `json:"startTime"`
+	EndTime        string   `json:"endTime"`
+	ParticipantIds []string `json:"participantIds"`
+}
+
+type RescheduleRequest struct {
+	DurationMinutes int   `json:"durationMinutes"`        // defaults to the meeting's current length
+	IgnoreDeclined  bool  `json:"ignoreDeclined"`         // as in ScheduleRequest
+	StepMinutes     int   `json:"stepMinutes,omitempty"`  // as in ScheduleRequest
+	AlignToClock    *bool `json:"alignToClock,omitempty"` // as in ScheduleRequest
+	TimeRange       struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	} `json:"timeRange"`
+}
+
+// RSVPRequest records an attendee's response to a meeting.
+type RSVPRequest struct {
+	UserID string `json:"userId"`
+	Status string `json:"status"` // "accepted", "declined", "tentative" or "needs-action"
+}
+
+// RSVPUpdate is one response recorded from an iTIP REPLY.
+type RSVPUpdate struct {
+	MeetingID string `json:"meetingId"`
+	UserID    string `json:"userId"`
+	Status    string `json:"status"`
+}
+
+// Meeting statuses reported in ScheduledMeetingResponse
+const (
+	StatusConfirmed = "confirmed"
+	StatusHeld      = "held"
+)
+
+type ProposalRequest struct {
+	ScheduleRequest
+	MaxProposals int `json:"maxProposals"`
+}
+
+type SlotProposal struct {
+	Rank              int                  `json:"rank"`
+	StartTime         string               `json:"startTime"`
+	EndTime           string               `json:"endTime"`
+	Score             int                  `json:"score"`
+	ParticipantScores map[string]int       `json:"participantScores"`
+	Conflicts         []OccurrenceConflict `json:"conflicts,omitempty"`
+	UnavailableIds    []string             `json:"unavailableIds,omitempty"`
+	ResourceIds       []string             `json:"resourceIds,omitempty"`
+}
+
+type ProposalResponse struct {
+	Title          string         `json:"title"`
+	ParticipantIds []string       `json:"participantIds"`
+	Proposals      []SlotProposal `json:"proposals"`
+}
+
+type UserProfile struct {
+	UserCode     string          `json:"userCode"`
+	Timezone     string          `json:"timezone"`
+	Email        string          `json:"email,omitempty"` // where meeting invitations are sent
+	WorkingHours []WorkingWindow `json:"workingHours"`
+}
+
+// UserDetails is a user as created and returned by the users API.
+type UserDetails struct {
+	UserCode     string          `json:"userCode"` // letters, digits, "-" and "_"
+	Name         string          `json:"name"`
+	Email        string          `json:"email,omitempty"`
+	Timezone     string          `json:"timezone"`
+	Organization string          `json:"organization,omitempty"` // see ScoringOptions
+	WorkingHours []WorkingWindow `json:"workingHours"`
+}
+
+// UserUpdateRequest changes only the fields that are set. Working hours
+// are replaced through the profile endpoint.
+type UserUpdateRequest struct {
+	Name     *string `json:"name,omitempty"`
+	Email    *string `json:"email,omitempty"`
+	Timezone *string `json:"timezone,omitempty"`
+	// "" takes the user out of their organization
+	Organization *string `json:"organization,omitempty"`
+}
+
+// UserList is one page of users.
+type UserList struct {
+	Users    []UserDetails `json:"users"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"pageSize"`
+	Total    int64         `json:"total"` // users matching the search across all pages
+}
+
+type WorkingWindow struct {
+	Weekday string `json:"weekday"` // "monday" ... "sunday"
+	Start   string `json:"start"`   // "09:00"
+	End     string `json:"end"`     // "17:30", or "24:00" for midnight
+}
+
+type FreeBusyRequest struct {
+	UserIds        []string `json:"userIDs"`
+	IgnoreDeclined bool     `json:"ignoreDeclined"` // as in ScheduleRequest
+	TimeRange      struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	} `json:"timeRange"`
+}
+
+type FreeBusyResponse struct {
+	Start string                `json:"start"`
+	End   string                `json:"end"`
+	Busy  map[string][]Interval `json:"busy"` // merged busy time per user
+	Free  []Interval            `json:"free"` // time when every user is free
+}
+
+type Interval struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type ImportResult struct {
+	Source    string       `json:"source"`
+	Created   int          `json:"created"`
+	Updated   int          `json:"updated"`
+	Unchanged int          `json:"unchanged"`
+	Removed   int          `json:"removed"` // cancelled, or no longer in the feed
+	Skipped   []ImportSkip `json:"skipped,omitempty"`
+}
+
+type ImportSkip struct {
+	UID    string `json:"uid"`
+	Reason string `json:"reason"`
+}
+
+// Scopes for editing a recurring series
+const (
+	ScopeThis      = "this"
+	ScopeFollowing = "following"
+	ScopeAll       = "all"
+)
+
+type EventUpdateRequest struct {
+	Scope      string  `json:"scope"`      // "this", "following" or "all" (default)
+	Occurrence string  `json:"occurrence"` // original start of the occurrence, required for "this" and "following"
+	Title      *string `json:"title"`
+	StartTime  *string `json:"startTime"`
+	EndTime    *string `json:"endTime"`
+}
+
+// EventRequest places an event on a user's own calendar, such as focus
+// time, travel or vacation.
+type EventRequest struct {
+	Title       string `json:"title"`
+	StartTime   string `json:"startTime"`
+	EndTime     string `json:"endTime"`
+	Transparent bool   `json:"transparent"` // free time: shown, but does not block scheduling
+	OnOverlap   string `json:"onOverlap"`   // "warn" (default) or "reject"
+}
+
+// UserEventUpdateRequest changes only the fields that are set.
+type UserEventUpdateRequest struct {
+	Title       *string `json:"title"`
+	StartTime   *string `json:"startTime"`
+	EndTime     *string `json:"endTime"`
+	Transparent *bool   `json:"transparent"`
+	OnOverlap   string  `json:"onOverlap"` // as in EventRequest
+}
+
+// What to do when a new or moved event overlaps the user's busy time
+const (
+	OverlapWarn   = "warn"
+	OverlapReject = "reject"
+)
+
+// EventResponse is a placed event with the busy entries it overlaps.
+type EventResponse struct {
+	model.Event
+	Overlaps []EventOverlap `json:"overlaps,omitempty"`
+}
+
+type EventOverlap struct {
+	EventID   uint   `json:"eventId,omitempty"`
+	MeetingID string `json:"meetingId,omitempty"`
+	HoldID    string `json:"holdId,omitempty"`
+	Title     string `json:"title"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
 }
 
 // Service types
@@ -32,39 +296,83 @@ type Slot struct {
 	End   time.Time
 }
 
-// Dummy data for testing
-func CreateDummyData(db *gorm.DB) error {
-	// Clear existing test data first
-	db.Where("event_code LIKE ?", "event%").Delete(&model.Event{})
-	db.Where("user_code LIKE ?", "user%").Delete(&model.User{})
+// Overlaps reports whether the half-open intervals [s.Start, s.End) and
+// [other.Start, other.End) share any time. Touching intervals do not overlap.
+func (s Slot) Overlaps(other Slot) bool {
+	return s.Start.Before(other.End) && s.End.After(other.Start)
+}
 
+// Dummy data for testing. Running it again recreates missing dummy users and
+// resets their dummy events, leaving existing users, including edits made
+// to the dummy users through the API, alone.
+func CreateDummyData(store Store) error {
 	// Create dummy users
 	users := []model.User{
 		{
 			UserCode: "user1",
 			Name:     "Alice Johnson",
+			Email:    "alice@example.com",
+			Timezone: "Asia/Kolkata",
 		},
 		{
 			UserCode: "user2",
 			Name:     "Bob Smith",
+			Email:    "bob@example.com",
+			Timezone: "Asia/Kolkata",
 		},
 		{
 			UserCode: "user3",
 			Name:     "Charlie Brown",
+			Email:    "charlie@example.com",
+			Timezone: "Asia/Kolkata",
 		},
 		{
 			UserCode: "user4",
 			Name:     "Diana Prince",
+			Email:    "diana@example.com",
+			Timezone: "Asia/Kolkata",
+			// Part-time: only takes meetings 10:00-16:00, Monday to Saturday
+			WorkingHours: []model.WorkingHours{
+				{Weekday: time.Monday, StartMinute: 10 * 60, EndMinute: 16 * 60},
+				{Weekday: time.Tuesday, StartMinute: 10 * 60, EndMinute: 16 * 60},
+				{Weekday: time.Wednesday, StartMinute: 10 * 60, EndMinute: 16 * 60},
+				{Weekday: time.Thursday, StartMinute: 10 * 60, EndMinute: 16 * 60},
+				{Weekday: time.Friday, StartMinute: 10 * 60, EndMinute: 16 * 60},
+				{Weekday: time.Saturday, StartMinute: 10 * 60, EndMinute: 16 * 60},
+			},
 		},
 		{
 			UserCode: "user5",
 			Name:     "Eve Wilson",
+			Email:    "eve@example.com",
+			Timezone: "Asia/Kolkata",
 		},
 	}
 
-	// Insert users
+	// Insert users that do not exist yet
 	for _, user := range users {
-		if err := db.Create(&user).Error; err != nil {
+		_, err := store.FindUser(user.UserCode)
+		if errors.Is(err, ErrNotFound) {
+			err = store.CreateUser(&user)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Dummy rooms and equipment, created once
+	resources := []model.Resource{
+		{ResourceCode: "room1", Name: "Board Room", Type: model.ResourceRoom, Capacity: 12, Location: "Floor 3", Features: "video,whiteboard"},
+		{ResourceCode: "room2", Name: "Huddle Room", Type: model.ResourceRoom, Capacity: 4, Location: "Floor 2", Features: "video"},
+		{ResourceCode: "room3", Name: "Library", Type: model.ResourceRoom, Capacity: 6, Location: "Floor 2", Features: "whiteboard"},
+		{ResourceCode: "projector1", Name: "Portable Projector", Type: model.ResourceProjector, Location: "Floor 2"},
+	}
+	for _, resource := range resources {
+		_, err := store.FindResource(resource.ResourceCode)
+		if errors.Is(err, ErrNotFound) {
+			err = store.CreateResource(&resource)
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -138,8 +446,36 @@ func CreateDummyData(db *gorm.DB) error {
 		},
 	}
 
+	// Recurring series, stored once and expanded when queried
+	events = append(events,
+		model.Event{
+			EventCode: "series1",
+			UserID:    "user5",
+			Title:     "Daily Sync",
+			StartTime: baseTime.AddDate(0, 0, -8).Add(30 * time.Minute), // 9:30 AM IST from 1 August
+			EndTime:   baseTime.AddDate(0, 0, -8).Add(45 * time.Minute), // 9:45 AM IST
+			RRule:     "FREQ=DAILY;COUNT=30",
+		},
+		model.Event{
+			EventCode: "series2",
+			UserID:    "user2",
+			Title:     "Weekly 1:1",
+			StartTime: baseTime.AddDate(0, 0, -5).Add(1 * time.Hour), // Mondays 10:00 AM IST from 4 August
+			EndTime:   baseTime.AddDate(0, 0, -5).Add(90 * time.Minute),
+			RRule:     "FREQ=WEEKLY;BYDAY=MO",
+		},
+	)
+
 	for _, event := range events {
-		if err := db.Create(&event).Error; err != nil {
+		// Also clears overrides and split-off parts of a series, coded
+		// "<series>-...", but not events whose codes merely start the same
+		if _, err := store.DeleteEventsByCode(event.EventCode); err != nil {
+			return err
+		}
+		if _, err := store.DeleteEventsByCodePrefix(event.EventCode + "-"); err != nil {
+			return err
+		}
+		if err := store.CreateEvent(&event); err != nil {
 			return err
 		}
 	}
@@ -312,3 +312,68 @@ func TestTimeRangeStruct(t *testing.T) {
 		t.Errorf("End time mismatch: expected %s, got %s", timeRange.End, unmarshaled.End)
 	}
 }
+
+func TestProposalRequest(t *testing.T) {
+	jsonData := `{
+		"title": "Design Review",
+		"userIDs": ["user1", "user2"],
+		"durationMinutes": 30,
+		"timeRange": {
+			"start": "2025-08-09T09:00:00+05:30",
+			"end": "2025-08-09T17:00:00+05:30"
+		},
+		"maxProposals": 5
+	}`
+
+	var req ProposalRequest
+	if err := json.Unmarshal([]byte(jsonData), &req); err != nil {
+		t.Fatalf("Failed to unmarshal proposal request: %v", err)
+	}
+
+	// Schedule fields are flattened into the proposal request
+	if req.Title != "Design Review" {
+		t.Errorf("Expected title 'Design Review', got '%s'", req.Title)
+	}
+	if len(req.ParticipantIds) != 2 {
+		t.Errorf("Expected 2 participants, got %d", len(req.ParticipantIds))
+	}
+	if req.DurationMinutes != 30 {
+		t.Errorf("Expected duration 30, got %d", req.DurationMinutes)
+	}
+	if req.TimeRange.Start != "2025-08-09T09:00:00+05:30" {
+		t.Errorf("Unexpected time range start: %s", req.TimeRange.Start)
+	}
+	if req.MaxProposals != 5 {
+		t.Errorf("Expected maxProposals 5, got %d", req.MaxProposals)
+	}
+}
+
+func TestRescheduleRequest(t *testing.T) {
+	jsonData := `{
+		"durationMinutes": 45,
+		"timeRange": {
+			"start": "2025-08-10T09:00:00+05:30",
+			"end": "2025-08-10T12:00:00+05:30"
+		}
+	}`
+
+	var req RescheduleRequest
+	if err := json.Unmarshal([]byte(jsonData), &req); err != nil {
+		t.Fatalf("Failed to unmarshal reschedule request: %v", err)
+	}
+	if req.DurationMinutes != 45 {
+		t.Errorf("Expected duration 45, got %d", req.DurationMinutes)
+	}
+	if req.TimeRange.End != "2025-08-10T12:00:00+05:30" {
+		t.Errorf("Unexpected time range end: %s", req.TimeRange.End)
+	}
+
+	// The excluded meeting is internal and never read from or written to JSON
+	var schedule ScheduleRequest
+	if err := json.Unmarshal([]byte(`{"ExcludeMeetingID": 7}`), &schedule); err != nil {
+		t.Fatalf("Failed to unmarshal schedule request: %v", err)
+	}
+	if schedule.ExcludeMeetingID != 0 {
+		t.Errorf("ExcludeMeetingID should not be settable from JSON")
+	}
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"smart-scheduler/model"
+	"time"
+)
+
+// ErrNotFound is returned by Store lookups that match no record.
+var ErrNotFound = errors.New("record not found")
+
+// ErrDuplicate is returned when a new record's code is already taken, even
+// by a transaction that committed after the caller looked.
+var ErrDuplicate = errors.New("duplicate record")
+
+// Store is the persistence layer behind the service package. GormStore keeps
+// the data in a SQL database and MemoryStore keeps it in process.
+type Store interface {
+	// Transaction runs fn against a Store whose writes are committed
+	// together, or discarded when fn returns an error.
+	Transaction(fn func(tx Store) error) error
+	// LockParticipants keeps other transactions from booking the same users
+	// until the current transaction ends.
+	LockParticipants(userIds []string) error
+	// LockResources does the same for rooms and equipment
+	LockResources(resourceIds []string) error
+
+	// Users are returned with their working hours. A taken user code
+	// gives ErrDuplicate.
+	CreateUser(user *model.User) error
+	FindUser(userCode string) (*model.User, error)
+	FindUsers(userCodes []string) ([]model.User, error) // unknown codes are skipped
+	// FindUserByEmail matches the address case-insensitively
+	FindUserByEmail(email string) (*model.User, error)
+	// ListUsers returns a page of users ordered by user code, with the
+	// number of users matching search. An empty search matches everyone,
+	// otherwise names containing it match, ignoring case.
+	ListUsers(search string, offset, limit int) ([]model.User, int64, error)
+	// UpdateUser saves the user's name, email and time zone, found by user
+	// code, leaving working hours as they are
+	UpdateUser(user *model.User) error
+	UpdateUserProfile(userCode, timezone, email string, hours []model.WorkingHours) error
+	// DeleteUser removes the user with their working hours, events, holds
+	// and meeting invitations. Meetings left without attendees go too, and
+	// the rest no longer name the user as organizer.
+	DeleteUser(userCode string) error
+
+	CreateEvent(event *model.Event) error
+	SaveEvent(event *model.Event) error
+	DeleteEvent(event *model.Event) error
+	FindEvent(id uint) (*model.Event, error)
+	// UserEvents returns every stored row of the user, series unexpanded
+	UserEvents(userId string) ([]model.Event, error)
+	// ImportedEvents returns the user's rows imported from source
+	ImportedEvents(userId, source string) ([]model.Event, error)
+	// OneOffEventsInRange returns events overlapping [start, end) that are
+	// neither a series nor an override of one occurrence
+	OneOffEventsInRange(userId string, start, end time.Time) ([]model.Event, error)
+	// SeriesInRange returns series masters with occurrences that may fall in
+	// [start, end)
+	SeriesInRange(userId string, start, end time.Time) ([]model.Event, error)
+	Overrides(seriesIds []uint) ([]model.Event, error)
+	FindOverride(seriesId uint, recurrenceId time.Time) (*model.Event, error)
+	// DeleteOverrides removes the series' overrides of occurrences starting
+	// at or after from
+	DeleteOverrides(seriesId uint, from time.Time) error
+	// MoveOverrides reattaches overrides of occurrences at or after from to
+	// another series
+	MoveOverrides(fromSeriesId, toSeriesId uint, from time.Time) error
+	// DeleteEventsByCode removes the event with exactly this code
+	DeleteEventsByCode(eventCode string) (int64, error)
+	DeleteEventsByCodePrefix(prefix string) (int64, error)
+	// DeleteLegacyMeetingEvents removes the per-participant rows of a
+	// meeting booked before meetings were stored, coded "<code>-<user>"
+	DeleteLegacyMeetingEvents(meetingCode string) (int64, error)
+
+	// Meetings are returned with their attendees
+	CreateMeeting(meeting *model.Meeting) error
+	FindMeeting(meetingCode string) (*model.Meeting, error)
+	// SaveMeeting updates the meeting's own fields, leaving attendees as
+	// they are
+	SaveMeeting(meeting *model.Meeting) error
+	DeleteMeeting(meeting *model.Meeting) error
+	ResetRSVPs(meetingId uint) error
+	// SetRSVP records one attendee's response. ErrNotFound means the user
+	// is not an attendee of the meeting.
+	SetRSVP(meetingId uint, userId, status string) error
+	MeetingsAttendedBy(userId string) ([]model.Meeting, error)
+	// MeetingsAttendedInRange returns the user's meetings with occurrences
+	// that may fall in [start, end)
+	MeetingsAttendedInRange(userId string, start, end time.Time) ([]model.Meeting, error)
+
+	CreateResource(resource *model.Resource) error // a taken code gives ErrDuplicate
+	FindResource(resourceCode string) (*model.Resource, error)
+	// ListResources returns every resource ordered by code
+	ListResources() ([]model.Resource, error)
+	// DeleteResource removes the resource and its meeting bookings, leaving
+	// the meetings themselves
+	DeleteResource(resourceCode string) error
+	// MeetingsBookingResourceInRange returns the meetings that booked the
+	// resource with occurrences that may fall in [start, end)
+	MeetingsBookingResourceInRange(resourceCode string, start, end time.Time) ([]model.Meeting, error)
+
+	CreateHold(hold *model.Hold) error
+	// ActiveHolds returns the user's holds overlapping [start, end) that
+	// have not expired at now
+	ActiveHolds(userId string, start, end, now time.Time) ([]model.Hold, error)
+	ActiveHoldsByCode(holdCode string, now time.Time) ([]model.Hold, error)
+	DeleteHolds(holdCode string) (int64, error)
+	DeleteExpiredHolds(now time.Time) (int64, error)
+}
@@ -1,6 +1,9 @@
 package routes
 
 import (
+	"errors"
+	"net/http"
+	"smart-scheduler/api"
 	"smart-scheduler/handlers"
 
 	"github.com/julienschmidt/httprouter"
@@ -8,11 +11,45 @@ import (
 
 func SetupRoutes() *httprouter.Router {
 	router := httprouter.New()
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.Error(w, r, errors.New("no such endpoint"), http.StatusNotFound)
+	})
+	router.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.Error(w, r, errors.New(r.Method+" is not allowed here"), http.StatusMethodNotAllowed)
+	})
 
 	router.POST("/api/v1/schedule", handlers.ScheduleMeeting)
+	router.POST("/api/v1/schedule/proposals", handlers.ProposeMeetingSlots)
+	router.POST("/api/v1/freebusy", handlers.GetFreeBusy)
+	router.POST("/api/v1/holds/:holdID/confirm", handlers.ConfirmHold)
+	router.DELETE("/api/v1/holds/:holdID", handlers.ReleaseHold)
+
+	router.POST("/api/v1/users", handlers.CreateUser)
+	router.PATCH("/api/v1/users/:userID", handlers.UpdateUser)
+	router.DELETE("/api/v1/users/:userID", handlers.DeleteUser)
+	router.PUT("/api/v1/users/:userID/profile", handlers.UpdateUserProfile)
+	router.POST("/api/v1/users/:userID/import", handlers.ImportCalendar)
+	router.POST("/api/v1/users/:userID/events", handlers.CreateUserEvent)
+	router.PATCH("/api/v1/users/:userID/events/:eventID", handlers.UpdateUserEvent)
+	router.DELETE("/api/v1/users/:userID/events/:eventID", handlers.DeleteUserEvent)
+	router.PATCH("/api/v1/events/:eventID", handlers.UpdateEvent)
+	router.DELETE("/api/v1/events/:eventID", handlers.DeleteEvent)
+	router.DELETE("/api/v1/meetings/:meetingID", handlers.CancelMeeting)
+	router.POST("/api/v1/meetings/:meetingID/reschedule", handlers.RescheduleMeeting)
+	router.POST("/api/v1/meetings/:meetingID/rsvp", handlers.RespondToMeeting)
+	router.POST("/api/v1/itip/reply", handlers.ProcessReply)
+	router.POST("/api/v1/resources", handlers.CreateResource)
+	router.DELETE("/api/v1/resources/:resourceID", handlers.DeleteResource)
 
 	// GET routes
 	router.GET("/api/v1/calendar/:userID", handlers.GetUserCalendar)
+	router.GET("/api/v1/users", handlers.ListUsers)
+	router.GET("/api/v1/users/:userID", handlers.GetUser)
+	router.GET("/api/v1/users/:userID/profile", handlers.GetUserProfile)
+	router.GET("/api/v1/meetings/:meetingID", handlers.GetMeeting)
+	router.GET("/api/v1/resources", handlers.ListResources)
+	router.GET("/api/v1/resources/:resourceID", handlers.GetResource)
+	router.GET("/api/v1/resources/:resourceID/calendar", handlers.GetResourceCalendar)
 
 	return router
 }
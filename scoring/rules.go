@@ -0,0 +1,186 @@
+package scoring
+
+import (
+	"smart-scheduler/repository"
+	"time"
+)
+
+// Rule names, as used in ScoringOptions.Weights
+const (
+	RuleTimeOfDay     = "timeOfDay"
+	RuleBuffer        = "buffer"
+	RuleFragmentation = "fragmentation"
+	RuleBackToBack    = "backToBack"
+	RuleLunch         = "lunch"
+	RuleDayLoad       = "dayLoad"
+)
+
+// Rule rates one aspect of a candidate slot for one participant; lower is
+// better. busy holds the participant's other busy intervals and loc their
+// time zone, or nil to use the slot's own zone.
+type Rule interface {
+	Name() string
+	Score(slot repository.Slot, busy []repository.Slot, loc *time.Location) int
+}
+
+// TimeOfDay prefers earlier slots and penalizes slots outside 9:00-24:00.
+type TimeOfDay struct{}
+
+func (TimeOfDay) Name() string { return RuleTimeOfDay }
+
+func (TimeOfDay) Score(slot repository.Slot, _ []repository.Slot, loc *time.Location) int {
+	startHour := local(slot.Start, loc).Hour()
+	switch {
+	case startHour >= 16:
+		return 3
+	case startHour >= 12:
+		return 2
+	case startHour >= 9:
+		return 1
+	default:
+		return 4 // outside working hours
+	}
+}
+
+// Buffer penalizes each busy interval ending or starting less than Gap away
+// from the slot.
+type Buffer struct {
+	Gap time.Duration
+}
+
+func (Buffer) Name() string { return RuleBuffer }
+
+func (r Buffer) Score(slot repository.Slot, busy []repository.Slot, _ *time.Location) int {
+	score := 0
+	for _, event := range busy {
+		if absDuration(slot.Start.Sub(event.End)) < r.Gap || absDuration(event.Start.Sub(slot.End)) < r.Gap {
+			score += 2 // no buffer before/after
+		}
+	}
+	return score
+}
+
+// Fragmentation penalizes slots that leave a free gap shorter than MinGap
+// before or after a busy interval.
+type Fragmentation struct {
+	MinGap time.Duration
+}
+
+func (Fragmentation) Name() string { return RuleFragmentation }
+
+func (r Fragmentation) Score(slot repository.Slot, busy []repository.Slot, _ *time.Location) int {
+	score := 0
+	for _, event := range busy {
+		gapBefore := slot.Start.Sub(event.End)
+		gapAfter := event.Start.Sub(slot.End)
+		if gapBefore > 0 && gapBefore < r.MinGap {
+			score++
+		}
+		if gapAfter > 0 && gapAfter < r.MinGap {
+			score++
+		}
+	}
+	return score
+}
+
+// BackToBack penalizes each meeting beyond Max in an unbroken run of
+// meetings that includes the slot. Meetings less than Gap apart are treated
+// as one run.
+type BackToBack struct {
+	Max int
+	Gap time.Duration
+}
+
+func (BackToBack) Name() string { return RuleBackToBack }
+
+func (r BackToBack) Score(slot repository.Slot, busy []repository.Slot, _ *time.Location) int {
+	run := 1
+	start, end := slot.Start, slot.End
+	for extended := true; extended; {
+		extended = false
+		for _, event := range busy {
+			switch {
+			case !event.End.Before(start.Add(-r.Gap)) && event.Start.Before(start):
+				start = event.Start
+			case !event.Start.After(end.Add(r.Gap)) && event.End.After(end):
+				end = event.End
+			default:
+				continue
+			}
+			run++
+			extended = true
+		}
+	}
+	if run > r.Max {
+		return run - r.Max
+	}
+	return 0
+}
+
+// Lunch penalizes slots overlapping the lunch break, given in minutes after
+// local midnight.
+type Lunch struct {
+	StartMinute int
+	EndMinute   int
+}
+
+func (Lunch) Name() string { return RuleLunch }
+
+func (r Lunch) Score(slot repository.Slot, _ []repository.Slot, loc *time.Location) int {
+	start := local(slot.Start, loc)
+	midnight := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	lunchStart := midnight.Add(time.Duration(r.StartMinute) * time.Minute)
+	lunchEnd := midnight.Add(time.Duration(r.EndMinute) * time.Minute)
+	if slot.Start.Before(lunchEnd) && slot.End.After(lunchStart) {
+		return 2
+	}
+	return 0
+}
+
+// DayLoad penalizes each started hour by which the participant's busy time
+// on the slot's local day, including the slot, exceeds MaxMinutes.
+type DayLoad struct {
+	MaxMinutes int
+}
+
+func (DayLoad) Name() string { return RuleDayLoad }
+
+func (r DayLoad) Score(slot repository.Slot, busy []repository.Slot, loc *time.Location) int {
+	start := local(slot.Start, loc)
+	dayStart := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	load := slot.End.Sub(slot.Start)
+	for _, event := range busy {
+		from, to := event.Start, event.End
+		if from.Before(dayStart) {
+			from = dayStart
+		}
+		if to.After(dayEnd) {
+			to = dayEnd
+		}
+		if to.After(from) {
+			load += to.Sub(from)
+		}
+	}
+
+	over := load - time.Duration(r.MaxMinutes)*time.Minute
+	if over <= 0 {
+		return 0
+	}
+	return int((over + time.Hour - 1) / time.Hour)
+}
+
+func local(t time.Time, loc *time.Location) time.Time {
+	if loc != nil {
+		return t.In(loc)
+	}
+	return t
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
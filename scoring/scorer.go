@@ -0,0 +1,232 @@
+package scoring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"smart-scheduler/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scorer rates a candidate slot for one participant; lower is better.
+type Scorer interface {
+	Score(slot repository.Slot, busy []repository.Slot, loc *time.Location) int
+}
+
+// Weighted is a rule and how much its score counts.
+type Weighted struct {
+	Rule   Rule
+	Weight int
+}
+
+// Composite is a Scorer summing the weighted scores of its rules.
+type Composite []Weighted
+
+func (c Composite) Score(slot repository.Slot, busy []repository.Slot, loc *time.Location) int {
+	score := 0
+	for _, w := range c {
+		if w.Weight != 0 {
+			score += w.Weight * w.Rule.Score(slot, busy, loc)
+		}
+	}
+	return score
+}
+
+// Profiles are the named presets, as weights per rule. Rules missing from a
+// profile are off unless the options give them a weight.
+var Profiles = map[string]map[string]int{
+	// The scheduler's original heuristics
+	"default": {RuleTimeOfDay: 1, RuleBuffer: 1, RuleFragmentation: 1},
+	"balanced": {
+		RuleTimeOfDay: 1, RuleBuffer: 1, RuleFragmentation: 1,
+		RuleBackToBack: 1, RuleLunch: 1, RuleDayLoad: 1,
+	},
+	// Keeps long stretches free for focused work
+	"focus": {
+		RuleTimeOfDay: 1, RuleBuffer: 1, RuleFragmentation: 3,
+		RuleBackToBack: 2, RuleLunch: 1, RuleDayLoad: 2,
+	},
+}
+
+// Defaults for the rule parameters
+const (
+	defaultBufferMinutes   = 15
+	defaultMinGapMinutes   = 30
+	defaultMaxBackToBack   = 2
+	defaultLunchStart      = "12:00"
+	defaultLunchEnd        = "13:00"
+	defaultMaxDailyMinutes = 360
+)
+
+// Default returns the scorer used when no options are given.
+func Default() Scorer {
+	scorer, _ := Build(repository.ScoringOptions{})
+	return scorer
+}
+
+// Build assembles the scorer described by opts: the profile's weights,
+// overridden by opts.Weights, with every rule tuned by the other options.
+func Build(opts repository.ScoringOptions) (Scorer, error) {
+	profile := opts.Profile
+	if profile == "" {
+		profile = "default"
+	}
+	preset, ok := Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown scoring profile %q", profile)
+	}
+
+	lunchStart, err := parseClock(orDefault(opts.LunchStart, defaultLunchStart))
+	if err != nil {
+		return nil, fmt.Errorf("invalid lunchStart: %w", err)
+	}
+	lunchEnd, err := parseClock(orDefault(opts.LunchEnd, defaultLunchEnd))
+	if err != nil {
+		return nil, fmt.Errorf("invalid lunchEnd: %w", err)
+	}
+	if lunchEnd <= lunchStart {
+		return nil, errors.New("lunchEnd must be after lunchStart")
+	}
+	if opts.BufferMinutes < 0 || opts.MinGapMinutes < 0 || opts.MaxBackToBack < 0 || opts.MaxDailyMinutes < 0 {
+		return nil, errors.New("scoring options must not be negative")
+	}
+
+	buffer := time.Duration(positiveOr(opts.BufferMinutes, defaultBufferMinutes)) * time.Minute
+	rules := []Rule{
+		TimeOfDay{},
+		Buffer{Gap: buffer},
+		Fragmentation{MinGap: time.Duration(positiveOr(opts.MinGapMinutes, defaultMinGapMinutes)) * time.Minute},
+		BackToBack{Max: positiveOr(opts.MaxBackToBack, defaultMaxBackToBack), Gap: buffer},
+		Lunch{StartMinute: lunchStart, EndMinute: lunchEnd},
+		DayLoad{MaxMinutes: positiveOr(opts.MaxDailyMinutes, defaultMaxDailyMinutes)},
+	}
+
+	for name, weight := range opts.Weights {
+		if !knownRule(rules, name) {
+			return nil, fmt.Errorf("unknown scoring rule %q", name)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("weight for %q must not be negative", name)
+		}
+	}
+
+	scorer := make(Composite, 0, len(rules))
+	for _, rule := range rules {
+		weight := preset[rule.Name()]
+		if w, ok := opts.Weights[rule.Name()]; ok {
+			weight = w
+		}
+		scorer = append(scorer, Weighted{Rule: rule, Weight: weight})
+	}
+	return scorer, nil
+}
+
+// Merge layers override on top of base: set fields in override win and
+// weights are combined rule by rule.
+func Merge(base repository.ScoringOptions, override *repository.ScoringOptions) repository.ScoringOptions {
+	if override == nil {
+		return base
+	}
+	merged := base
+	weights := make(map[string]int, len(base.Weights)+len(override.Weights))
+	for name, w := range base.Weights {
+		weights[name] = w
+	}
+	if override.Profile != "" {
+		// A different profile starts from its own weights
+		merged.Profile = override.Profile
+		weights = make(map[string]int, len(override.Weights))
+	}
+	for name, w := range override.Weights {
+		weights[name] = w
+	}
+	merged.Weights = weights
+
+	if override.BufferMinutes != 0 {
+		merged.BufferMinutes = override.BufferMinutes
+	}
+	if override.MinGapMinutes != 0 {
+		merged.MinGapMinutes = override.MinGapMinutes
+	}
+	if override.MaxBackToBack != 0 {
+		merged.MaxBackToBack = override.MaxBackToBack
+	}
+	if override.LunchStart != "" {
+		merged.LunchStart = override.LunchStart
+	}
+	if override.LunchEnd != "" {
+		merged.LunchEnd = override.LunchEnd
+	}
+	if override.MaxDailyMinutes != 0 {
+		merged.MaxDailyMinutes = override.MaxDailyMinutes
+	}
+	return merged
+}
+
+// ParseWeights reads weights written as "fragmentation=3,lunch=0".
+func ParseWeights(value string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q, expected name=weight", part)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for %q: %w", name, err)
+		}
+		weights[strings.TrimSpace(name)] = weight
+	}
+	return weights, nil
+}
+
+// ParseOrganizations reads scoring options by organization name, written as
+// a JSON object such as {"sales": {"weights": {"lunch": 2}}}.
+func ParseOrganizations(value string) (map[string]repository.ScoringOptions, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var orgs map[string]repository.ScoringOptions
+	if err := json.Unmarshal([]byte(value), &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+func knownRule(rules []Rule, name string) bool {
+	for _, rule := range rules {
+		if rule.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock converts "HH:MM" to minutes after midnight.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,175 @@
+package scoring
+
+import (
+	"smart-scheduler/repository"
+	"testing"
+	"time"
+)
+
+// getISTTimezone returns IST timezone (UTC+05:30)
+func getISTTimezone() *time.Location {
+	return time.FixedZone("IST", 5*60*60+30*60)
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2025, 8, 9, hour, minute, 0, 0, getISTTimezone())
+}
+
+func slot(startHour, startMinute, endHour, endMinute int) repository.Slot {
+	return repository.Slot{Start: at(startHour, startMinute), End: at(endHour, endMinute)}
+}
+
+func TestDefaultMatchesOriginalHeuristics(t *testing.T) {
+	busy := []repository.Slot{slot(9, 0, 10, 0)}
+
+	tests := []struct {
+		name     string
+		slot     repository.Slot
+		expected int
+	}{
+		{"Morning, right after a meeting", slot(10, 0, 11, 0), 1 + 2},
+		{"Morning, short gap after a meeting", slot(10, 20, 11, 20), 1 + 1},
+		{"Afternoon, well clear", slot(14, 0, 15, 0), 2},
+		{"Late afternoon", slot(16, 0, 17, 0), 3},
+		{"Early morning", slot(7, 0, 8, 0), 4},
+	}
+
+	scorer := Default()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if score := scorer.Score(tt.slot, busy, nil); score != tt.expected {
+				t.Errorf("Expected score %d, got %d", tt.expected, score)
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	busy := []repository.Slot{slot(9, 0, 10, 0), slot(10, 0, 11, 0)}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		slot     repository.Slot
+		expected int
+	}{
+		{"Back-to-back run of three", BackToBack{Max: 2}, slot(11, 0, 12, 0), 1},
+		{"Back-to-back within limit", BackToBack{Max: 3}, slot(11, 0, 12, 0), 0},
+		{"Back-to-back with a break", BackToBack{Max: 1}, slot(13, 0, 14, 0), 0},
+		{"Lunch overlap", Lunch{StartMinute: 12 * 60, EndMinute: 13 * 60}, slot(12, 30, 13, 30), 2},
+		{"Lunch untouched", Lunch{StartMinute: 12 * 60, EndMinute: 13 * 60}, slot(13, 0, 14, 0), 0},
+		{"Day load under limit", DayLoad{MaxMinutes: 180}, slot(14, 0, 15, 0), 0},
+		{"Day load over limit", DayLoad{MaxMinutes: 120}, slot(14, 0, 15, 30), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if score := tt.rule.Score(tt.slot, busy, nil); score != tt.expected {
+				t.Errorf("Expected score %d, got %d", tt.expected, score)
+			}
+		})
+	}
+}
+
+func TestLunchUsesParticipantTimezone(t *testing.T) {
+	// 12:30 IST is 07:00 UTC, well before a UTC lunch
+	rule := Lunch{StartMinute: 12 * 60, EndMinute: 13 * 60}
+	if score := rule.Score(slot(12, 30, 13, 0), nil, time.UTC); score != 0 {
+		t.Errorf("Expected no lunch penalty in UTC, got %d", score)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	s := slot(12, 0, 13, 0)
+
+	scorer, err := Build(repository.ScoringOptions{Weights: map[string]int{RuleLunch: 3, RuleTimeOfDay: 0}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if score := scorer.Score(s, nil, nil); score != 6 {
+		t.Errorf("Expected only the weighted lunch penalty 6, got %d", score)
+	}
+
+	scorer, err = Build(repository.ScoringOptions{Profile: "balanced", LunchStart: "13:00", LunchEnd: "14:00"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if score := scorer.Score(s, nil, nil); score != 2 {
+		t.Errorf("Expected the moved lunch break not to count, got %d", score)
+	}
+
+	invalid := []repository.ScoringOptions{
+		{Profile: "unknown"},
+		{Weights: map[string]int{"unknown": 1}},
+		{Weights: map[string]int{RuleBuffer: -1}},
+		{LunchStart: "noon"},
+		{LunchStart: "14:00", LunchEnd: "13:00"},
+		{MaxBackToBack: -1},
+	}
+	for _, opts := range invalid {
+		if _, err := Build(opts); err == nil {
+			t.Errorf("Expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := repository.ScoringOptions{
+		Profile:       "focus",
+		Weights:       map[string]int{RuleLunch: 2},
+		BufferMinutes: 10,
+	}
+
+	merged := Merge(base, &repository.ScoringOptions{Weights: map[string]int{RuleDayLoad: 0}, BufferMinutes: 5})
+	if merged.Profile != "focus" || merged.BufferMinutes != 5 {
+		t.Errorf("Unexpected merge result: %+v", merged)
+	}
+	if merged.Weights[RuleLunch] != 2 || len(merged.Weights) != 2 {
+		t.Errorf("Expected weights to be combined, got %v", merged.Weights)
+	}
+	if base.Weights[RuleDayLoad] != 0 || len(base.Weights) != 1 {
+		t.Error("Merge must not modify the base options")
+	}
+
+	// Switching profile drops the base profile's weight overrides
+	merged = Merge(base, &repository.ScoringOptions{Profile: "default"})
+	if merged.Profile != "default" || len(merged.Weights) != 0 {
+		t.Errorf("Unexpected merge result: %+v", merged)
+	}
+
+	if merged := Merge(base, nil); merged.Profile != "focus" {
+		t.Errorf("Expected base options without an override, got %+v", merged)
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	weights, err := ParseWeights(" fragmentation=3, lunch=0 ,")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(weights) != 2 || weights[RuleFragmentation] != 3 || weights[RuleLunch] != 0 {
+		t.Errorf("Unexpected weights: %v", weights)
+	}
+
+	for _, value := range []string{"lunch", "lunch=high"} {
+		if _, err := ParseWeights(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestParseOrganizations(t *testing.T) {
+	orgs, err := ParseOrganizations(`{"research": {"profile": "focus"}, "sales": {"weights": {"lunch": 2}}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(orgs) != 2 || orgs["research"].Profile != "focus" || orgs["sales"].Weights[RuleLunch] != 2 {
+		t.Errorf("Unexpected organizations: %+v", orgs)
+	}
+	if orgs, err := ParseOrganizations(" "); err != nil || orgs != nil {
+		t.Errorf("Expected no organizations, got %+v, %v", orgs, err)
+	}
+	if _, err := ParseOrganizations(`{"research": "focus"}`); err == nil {
+		t.Error("Expected an error for options that are not an object")
+	}
+}
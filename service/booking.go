@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
+	"smart-scheduler/recurrence"
+	"smart-scheduler/repository"
+	"time"
+)
+
+// ConflictError reports that a participant's or resource's calendar changed
+// between the slot search and the booking, so the chosen slot is no longer
+// free.
+type ConflictError struct {
+	UserID     string
+	ResourceID string // set instead of UserID for rooms and equipment
+	Start      time.Time
+	End        time.Time
+}
+
+func (e *ConflictError) Error() string {
+	who := "participant " + e.UserID
+	if e.ResourceID != "" {
+		who = "resource " + e.ResourceID
+	}
+	return fmt.Sprintf("%s is no longer free from %s to %s",
+		who, e.Start.Format(time.RFC3339), e.End.Format(time.RFC3339))
+}
+
+func (e *ConflictError) Kind() apperr.Kind {
+	return apperr.Kind{Code: "booking_conflict", Title: "Booked by another request", Status: http.StatusConflict}
+}
+
+// bookingCheck describes what the re-check at booking time may ignore.
+type bookingCheck struct {
+	excludeMeetingID uint   // the meeting being moved
+	excludeHoldCode  string // the holds being confirmed
+	ignoreDeclined   bool   // meetings the user declined are free time
+	// Occurrences of a recurring meeting already known to collide
+	known []repository.OccurrenceConflict
+}
+
+// searchCheck is what the slot search for req leaves out of busy time.
+func searchCheck(req repository.ScheduleRequest) bookingCheck {
+	return bookingCheck{excludeMeetingID: req.ExcludeMeetingID, ignoreDeclined: req.IgnoreDeclined}
+}
+
+// reserve locks the participants and re-checks that every one of userIds is
+// still free for each occurrence of [start, end) repeating by rrule. It must
+// run inside the transaction that writes the booking.
+func reserve(tx repository.Store, userIds []string, start, end time.Time, rrule string, check bookingCheck) error {
+	if err := tx.LockParticipants(userIds); err != nil {
+		return storageFailure(err)
+	}
+
+	occurrences := []time.Time{start}
+	horizon := end
+	if rrule != "" {
+		rule, err := recurrence.Parse(rrule, start.Location())
+		if err != nil {
+			return err
+		}
+		occurrences = nil
+		rule.Each(start, func(occ time.Time) bool {
+			occurrences = append(occurrences, occ)
+			return true
+		})
+		if len(occurrences) > 0 {
+			horizon = occurrences[len(occurrences)-1].Add(end.Sub(start))
+		}
+	}
+
+	duration := end.Sub(start)
+	for _, userId := range userIds {
+		busy, err := busyIntervals(tx, userId, start, horizon, check)
+		if err != nil {
+			return storageFailure(err)
+		}
+		for _, occ := range occurrences {
+			if check.isKnown(occ, userId) {
+				continue
+			}
+			for _, b := range busy {
+				if b.Overlaps(repository.Slot{Start: occ, End: occ.Add(duration)}) {
+					log.Printf("Booking conflict: %s is busy %v to %v", userId, b.Start, b.End)
+					return &ConflictError{UserID: userId, Start: occ, End: occ.Add(duration)}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c bookingCheck) isKnown(occ time.Time, userId string) bool {
+	for _, conflict := range c.known {
+		t, err := time.Parse(time.RFC3339, conflict.StartTime)
+		if err == nil && t.Equal(occ) && contains(conflict.ParticipantIds, userId) {
+			return true
+		}
+	}
+	return false
+}
+
+// busyIntervals returns userId's busy time overlapping [start, end): their
+// events, with series expanded, and active holds, minus transparent events
+// and what check excludes.
+func busyIntervals(tx repository.Store, userId string, start, end time.Time, check bookingCheck) ([]repository.Slot, error) {
+	events, err := userEventsInRange(tx, userId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var busy []repository.Slot
+	for _, e := range events {
+		if e.Transparent {
+			continue
+		}
+		if check.excludeMeetingID != 0 && e.Meeting != nil && e.Meeting.ID == check.excludeMeetingID {
+			continue
+		}
+		if check.ignoreDeclined && e.Meeting != nil && e.ResponseStatus == model.RSVPDeclined {
+			continue
+		}
+		busy = append(busy, repository.Slot{Start: e.StartTime, End: e.EndTime})
+	}
+
+	// Tentative holds block the slot until they expire or are confirmed
+	holds, err := tx.ActiveHolds(userId, start, end, time.Now())
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	for _, h := range holds {
+		if check.excludeHoldCode != "" && h.HoldCode == check.excludeHoldCode {
+			continue
+		}
+		busy = append(busy, repository.Slot{Start: h.StartTime, End: h.EndTime})
+	}
+	return busy, nil
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
+	"smart-scheduler/repository"
+	"strings"
+	"time"
+)
+
+// Requests the event endpoints never accept, whatever the state of the
+// calendar
+var (
+	ErrImportedEvent  = apperr.New(apperr.Kind{Code: "imported_event", Title: "Imported events cannot be edited", Status: http.StatusUnprocessableEntity}, "imported events are changed by importing their calendar again")
+	ErrRecurringEvent = apperr.New(apperr.Kind{Code: "recurring_event", Title: "Recurring events need a scope", Status: http.StatusUnprocessableEntity}, "recurring events are edited through /api/v1/events/{eventID} with a scope")
+)
+
+// OverlapError reports that an event would overlap the user's busy time
+// when the request asked for overlaps to be rejected.
+type OverlapError struct {
+	Overlaps []repository.EventOverlap
+}
+
+func (e *OverlapError) Error() string {
+	first := e.Overlaps[0]
+	msg := fmt.Sprintf("event overlaps %q from %s to %s", first.Title, first.StartTime, first.EndTime)
+	if more := len(e.Overlaps) - 1; more > 0 {
+		msg += fmt.Sprintf(" and %d more", more)
+	}
+	return msg
+}
+
+func (e *OverlapError) Kind() apperr.Kind {
+	return apperr.Kind{Code: "event_overlap", Title: "Event overlaps busy time", Status: http.StatusConflict}
+}
+
+// CreateUserEvent places a one-off event on the user's own calendar. The
+// response lists the busy entries it overlaps, or the call fails with an
+// OverlapError when req.OnOverlap is "reject".
+func CreateUserEvent(userId string, req repository.EventRequest) (*repository.EventResponse, error) {
+	var v validator
+	validateOnOverlap(&v, req.OnOverlap)
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		v.add("title", apperr.CodeRequired, "is required")
+	}
+	start, startOK := v.time("startTime", req.StartTime)
+	end, endOK := v.time("endTime", req.EndTime)
+	if startOK && endOK && !end.After(start) {
+		v.add("endTime", apperr.CodeOutOfRange, "must be after startTime")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	resp := &repository.EventResponse{Event: model.Event{
+		EventCode:   newCode("event"),
+		UserID:      userId,
+		Title:       title,
+		StartTime:   start,
+		EndTime:     end,
+		Transparent: req.Transparent,
+	}}
+	err := store.Transaction(func(tx repository.Store) error {
+		var err error
+		if _, err = findUser(tx, userId); err != nil {
+			return err
+		}
+		if resp.Overlaps, err = checkOverlaps(tx, &resp.Event, req.OnOverlap); err != nil {
+			return err
+		}
+		return storageFailure(tx.CreateEvent(&resp.Event))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Placed event %s for %s from %v to %v", resp.EventCode, userId, start, end)
+	return resp, nil
+}
+
+// UpdateUserEvent moves, renames or changes the transparency of one of the
+// user's own one-off events, checking overlaps as CreateUserEvent does.
+func UpdateUserEvent(userId string, eventId uint, req repository.UserEventUpdateRequest) (*repository.EventResponse, error) {
+	var v validator
+	validateOnOverlap(&v, req.OnOverlap)
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		v.add("title", apperr.CodeRequired, "cannot be empty")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	resp := &repository.EventResponse{}
+	err := store.Transaction(func(tx repository.Store) error {
+		event, err := findUserEvent(tx, userId, eventId)
+		if err != nil {
+			return err
+		}
+		if event.IsRecurring() || event.RecurringEventID != nil {
+			return ErrRecurringEvent
+		}
+
+		start, end, err := retime(event.StartTime, event.EndTime, repository.EventUpdateRequest{StartTime: req.StartTime, EndTime: req.EndTime})
+		if err != nil {
+			return err
+		}
+		event.StartTime, event.EndTime = start, end
+		if req.Title != nil {
+			event.Title = strings.TrimSpace(*req.Title)
+		}
+		if req.Transparent != nil {
+			event.Transparent = *req.Transparent
+		}
+
+		if resp.Overlaps, err = checkOverlaps(tx, event, req.OnOverlap); err != nil {
+			return err
+		}
+		resp.Event = *event
+		return storageFailure(tx.SaveEvent(event))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteUserEvent removes one of the user's own one-off events. Series and
+// their overrides are deleted through DeleteEvent, which takes a scope.
+func DeleteUserEvent(userId string, eventId uint) error {
+	return store.Transaction(func(tx repository.Store) error {
+		event, err := findUserEvent(tx, userId, eventId)
+		if err != nil {
+			return err
+		}
+		if event.IsRecurring() || event.RecurringEventID != nil {
+			return ErrRecurringEvent
+		}
+		return deleteEvent(tx, event, repository.ScopeAll, "")
+	})
+}
+
+// findUserEvent looks up an event placed on userId's calendar. Events of
+// other users are reported as not found.
+func findUserEvent(tx repository.Store, userId string, eventId uint) (*model.Event, error) {
+	if _, err := findUser(tx, userId); err != nil {
+		return nil, err
+	}
+	event, err := findEvent(tx, eventId)
+	if err != nil {
+		return nil, err
+	}
+	if event.UserID != userId {
+		return nil, fmt.Errorf("%w: %d", ErrEventNotFound, eventId)
+	}
+	if event.Source != "" {
+		return nil, ErrImportedEvent
+	}
+	return event, nil
+}
+
+func validateOnOverlap(v *validator, onOverlap string) {
+	switch onOverlap {
+	case "", repository.OverlapWarn, repository.OverlapReject:
+		return
+	}
+	v.add("onOverlap", apperr.CodeInvalidValue, "must be warn or reject")
+}
+
+// checkOverlaps lists the entries on the user's calendar that block time
+// during event, other than event itself and meetings the user declined.
+// Active holds count, as they do for the scheduler. Transparent events
+// neither block nor are blocked. With onOverlap
+// "reject" any overlap is an OverlapError.
+func checkOverlaps(tx repository.Store, event *model.Event, onOverlap string) ([]repository.EventOverlap, error) {
+	if event.Transparent {
+		return nil, nil
+	}
+	if onOverlap == repository.OverlapReject {
+		// Keep a concurrent booking from filling the slot before we commit
+		if err := tx.LockParticipants([]string{event.UserID}); err != nil {
+			return nil, storageFailure(err)
+		}
+	}
+
+	existing, err := userEventsInRange(tx, event.UserID, event.StartTime, event.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	var overlaps []repository.EventOverlap
+	for _, e := range existing {
+		if e.Transparent || e.ResponseStatus == model.RSVPDeclined || (e.Meeting == nil && event.ID != 0 && e.ID == event.ID) {
+			continue
+		}
+		overlap := repository.EventOverlap{
+			Title:     e.Title,
+			StartTime: e.StartTime.Format(time.RFC3339),
+			EndTime:   e.EndTime.Format(time.RFC3339),
+		}
+		if e.Meeting != nil {
+			overlap.MeetingID = e.Meeting.MeetingCode
+		} else {
+			overlap.EventID = e.ID
+		}
+		overlaps = append(overlaps, overlap)
+	}
+
+	holds, err := tx.ActiveHolds(event.UserID, event.StartTime, event.EndTime, time.Now())
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	for _, h := range holds {
+		overlaps = append(overlaps, repository.EventOverlap{
+			HoldID:    h.HoldCode,
+			Title:     h.Title,
+			StartTime: h.StartTime.Format(time.RFC3339),
+			EndTime:   h.EndTime.Format(time.RFC3339),
+		})
+	}
+
+	if len(overlaps) > 0 && onOverlap == repository.OverlapReject {
+		return nil, &OverlapError{Overlaps: overlaps}
+	}
+	return overlaps, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"smart-scheduler/ical"
+	"smart-scheduler/model"
+	"smart-scheduler/recurrence"
+	"time"
+)
+
+// uidDomain qualifies the UIDs of exported events so they stay unique in
+// calendars that mix several sources.
+const uidDomain = "smart-scheduler"
+
+func uidFor(code string) string {
+	return code + "@" + uidDomain
+}
+
+// eventUID keeps the original UID of imported events.
+func eventUID(e model.Event) string {
+	if e.UID != "" {
+		return e.UID
+	}
+	return uidFor(e.EventCode)
+}
+
+// ExportCalendar builds userId's whole calendar as iCalendar data, in the
+// user's time zone. Series are exported once with their RRULE and EXDATEs,
+// and overridden occurrences as instances of the series' UID.
+func ExportCalendar(userId string) (*ical.Calendar, error) {
+	user, err := findUser(store, userId)
+	if err != nil {
+		return nil, err
+	}
+	events, err := store.UserEvents(userId)
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	meetings, err := userMeetings(store, userId)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, meetings...)
+	sortByStart(events)
+
+	name := user.Name
+	if name == "" {
+		name = user.UserCode
+	}
+	cal := &ical.Calendar{Name: name, TimeZone: user.Location()}
+
+	uids := make(map[uint]string, len(events))
+	for _, e := range events {
+		if e.Meeting == nil {
+			uids[e.ID] = eventUID(e)
+		}
+	}
+	stamp := time.Now()
+	for _, e := range events {
+		cal.Events = append(cal.Events, calendarEvent(e, uids, stamp))
+	}
+	return cal, nil
+}
+
+// calendarEvent converts a stored event, series or meeting entry into a
+// VEVENT. uids maps stored event IDs to UIDs so overrides can use their
+// series' UID.
+func calendarEvent(e model.Event, uids map[uint]string, stamp time.Time) ical.Event {
+	event := ical.Event{
+		UID:         eventUID(e),
+		Stamp:       stamp,
+		Start:       e.StartTime,
+		End:         e.EndTime,
+		Summary:     e.Title,
+		Status:      "CONFIRMED",
+		Transparent: e.Transparent,
+	}
+	if e.IsRecurring() {
+		// Normalise the stored rule; UNTIL is always written in UTC
+		if rule, err := recurrence.Parse(e.RRule, e.StartTime.Location()); err == nil {
+			event.RRule = rule.String()
+		}
+		event.ExDates = e.ExceptionDates()
+	}
+	if e.RecurringEventID != nil && e.RecurrenceID != nil {
+		if uid, ok := uids[*e.RecurringEventID]; ok {
+			event.UID = uid
+			event.RecurrenceID = e.RecurrenceID
+		}
+	}
+	if m := e.Meeting; m != nil {
+		event.Description = m.Description
+		event.Location = m.Location
+	}
+	return event
+}
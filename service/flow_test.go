@@ -0,0 +1,1169 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
+	"smart-scheduler/notify"
+	"smart-scheduler/repository"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useMemoryStore points the service at a fresh in-memory store holding the
+// dummy data for the duration of the test.
+func useMemoryStore(t *testing.T) *repository.MemoryStore {
+	t.Helper()
+	memory := repository.NewMemoryStore()
+	if err := repository.CreateDummyData(memory); err != nil {
+		t.Fatalf("Failed to create dummy data: %v", err)
+	}
+	previous := store
+	SetStore(memory)
+	t.Cleanup(func() { SetStore(previous) })
+	return memory
+}
+
+func scheduleRequest(userIds []string, start, end string) repository.ScheduleRequest {
+	req := repository.ScheduleRequest{
+		Title:           "Planning",
+		ParticipantIds:  userIds,
+		DurationMinutes: 60,
+	}
+	req.TimeRange.Start = start
+	req.TimeRange.End = end
+	return req
+}
+
+func TestScheduleEventFlow(t *testing.T) {
+	useMemoryStore(t)
+
+	req := scheduleRequest([]string{"user1", "user2"}, "2025-08-09T09:00:00+05:30", "2025-08-09T17:00:00+05:30")
+	resp, err := ScheduleEvent(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StartTime != "2025-08-09T09:30:00+05:30" || resp.Status != repository.StatusConfirmed {
+		t.Errorf("Expected a confirmed meeting at 09:30, got %+v", resp)
+	}
+
+	// The meeting shows on both calendars and blocks the slot
+	for _, userId := range []string{"user1", "user2"} {
+		events, err := GetCalendarEvents(userId, "2025-08-09T09:00:00+05:30", "2025-08-09T11:00:00+05:30")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		found := false
+		for _, e := range events {
+			if e.Meeting != nil && e.Meeting.MeetingCode == resp.MeetingID {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected the meeting on %s's calendar", userId)
+		}
+	}
+
+	again, err := ScheduleEvent(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if again.StartTime == resp.StartTime {
+		t.Error("Expected the second meeting to avoid the first")
+	}
+}
+
+func TestProposeSlotsDoesNotBook(t *testing.T) {
+	useMemoryStore(t)
+
+	req := repository.ProposalRequest{
+		ScheduleRequest: scheduleRequest([]string{"user1", "user2"}, "2025-08-09T09:00:00+05:30", "2025-08-09T17:00:00+05:30"),
+		MaxProposals:    2,
+	}
+	resp, err := ProposeSlots(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Proposals) != 2 || resp.Proposals[0].Rank != 1 {
+		t.Fatalf("Expected 2 ranked proposals, got %+v", resp.Proposals)
+	}
+
+	events, err := GetCalendarEvents("user1", "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, e := range events {
+		if e.Meeting != nil {
+			t.Errorf("Proposals must not book anything, found %+v", e)
+		}
+	}
+}
+
+func TestOrganizationScoring(t *testing.T) {
+	useMemoryStore(t)
+	t.Cleanup(func() { SetOrganizationScoring(nil) })
+
+	// Slots later in the day cost more unless the organization turns time of
+	// day off
+	totalScore := func(req repository.ScheduleRequest) int {
+		t.Helper()
+		resp, err := ProposeSlots(repository.ProposalRequest{ScheduleRequest: req, MaxProposals: 20})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		total := 0
+		for _, p := range resp.Proposals {
+			total += p.Score
+		}
+		return total
+	}
+	req := scheduleRequest([]string{"user3"}, "2025-08-11T09:00:00+05:30", "2025-08-11T17:00:00+05:30")
+	req.Scoring = &repository.ScoringOptions{Weights: map[string]int{"buffer": 0, "fragmentation": 0}}
+	if err := SetOrganizationScoring(map[string]repository.ScoringOptions{"research": {Weights: map[string]int{"timeOfDay": 0}}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if totalScore(req) == 0 {
+		t.Fatal("Expected time of day to count for an organizer outside the organization")
+	}
+
+	research := "research"
+	if _, err := UpdateUser("user3", repository.UserUpdateRequest{Organization: &research}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := totalScore(req); got != 0 {
+		t.Errorf("Expected the organization to turn time of day off, got a total score of %d", got)
+	}
+	req.Scoring.Weights["timeOfDay"] = 1
+	if totalScore(req) == 0 {
+		t.Error("Expected the request's weights to override the organization's")
+	}
+
+	if err := SetOrganizationScoring(map[string]repository.ScoringOptions{"research": {Profile: "unknown"}}); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
+
+func TestHoldFlow(t *testing.T) {
+	useMemoryStore(t)
+
+	req := scheduleRequest([]string{"user1", "user2"}, "2025-08-09T09:00:00+05:30", "2025-08-09T17:00:00+05:30")
+	req.HoldMinutes = 15
+	held, err := ScheduleEvent(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if held.Status != repository.StatusHeld || held.HoldID == "" {
+		t.Fatalf("Expected a hold, got %+v", held)
+	}
+
+	// Active holds are busy time for other requests
+	req.HoldMinutes = 0
+	other, err := ScheduleEvent(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if other.StartTime == held.StartTime {
+		t.Error("Expected the held slot to be avoided")
+	}
+
+	confirmed, err := ConfirmHold(held.HoldID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if confirmed.StartTime != held.StartTime || confirmed.Status != repository.StatusConfirmed {
+		t.Errorf("Expected the held slot to be booked, got %+v", confirmed)
+	}
+	if _, err := ConfirmHold(held.HoldID); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("Expected ErrHoldNotFound after confirming, got %v", err)
+	}
+}
+
+func TestRescheduleAndCancelMeeting(t *testing.T) {
+	useMemoryStore(t)
+
+	req := scheduleRequest([]string{"user1", "user2"}, "2025-08-09T09:00:00+05:30", "2025-08-09T17:00:00+05:30")
+	booked, err := ScheduleEvent(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The meeting's own slot is free when moving it
+	var move repository.RescheduleRequest
+	move.TimeRange.Start = booked.StartTime
+	move.TimeRange.End = booked.EndTime
+	moved, err := RescheduleMeeting(booked.MeetingID, move)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if moved.StartTime != booked.StartTime {
+		t.Errorf("Expected the meeting to keep its slot, got %s", moved.StartTime)
+	}
+
+	if _, err := CancelMeeting(booked.MeetingID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := GetMeeting(booked.MeetingID); !errors.Is(err, ErrMeetingNotFound) {
+		t.Errorf("Expected ErrMeetingNotFound after cancelling, got %v", err)
+	}
+}
+
+func TestCancelMeetingOnlyTakesLegacyCodes(t *testing.T) {
+	memory := useMemoryStore(t)
+
+	// A series override shares its master's code as a prefix
+	series, _ := GetCalendarEvents("user5", "2025-08-09T09:00:00+05:30", "2025-08-09T10:00:00+05:30")
+	title := "Moved sync"
+	if _, err := UpdateEvent(*series[0].RecurringEventID, repository.EventUpdateRequest{Title: &title, Scope: repository.ScopeThis, Occurrence: "2025-08-09T09:30:00+05:30"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := CancelMeeting("series1"); !errors.Is(err, ErrMeetingNotFound) {
+		t.Errorf("Expected ErrMeetingNotFound for an event code, got %v", err)
+	}
+	events, _ := GetCalendarEvents("user5", "2025-08-09T09:00:00+05:30", "2025-08-09T10:00:00+05:30")
+	if len(events) != 1 || events[0].Title != title {
+		t.Errorf("Expected the override to stay, got %+v", events)
+	}
+
+	// Meetings booked as one row per participant are still cancelled
+	start, _ := time.Parse(time.RFC3339, "2025-08-11T10:00:00+05:30")
+	for _, userId := range []string{"user1", "user2"} {
+		if err := memory.CreateEvent(&model.Event{EventCode: "meeting-20250801100000-" + userId, UserID: userId, Title: "Old meeting", StartTime: start, EndTime: start.Add(time.Hour)}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if _, err := CancelMeeting("meeting-20250801100000"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if events, _ := memory.UserEvents("user2"); len(events) != 3 {
+		t.Errorf("Expected only user2's dummy events to remain, got %d", len(events))
+	}
+}
+
+type recordingMailer struct {
+	sent []notify.Message
+}
+
+func (m *recordingMailer) Send(msg notify.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestMeetingInvitations(t *testing.T) {
+	useMemoryStore(t)
+	mailer := &recordingMailer{}
+	SetMailer(mailer, "")
+	t.Cleanup(func() { SetMailer(nil, "") })
+
+	req := scheduleRequest([]string{"user1", "user2"}, "2025-08-09T09:00:00+05:30", "2025-08-09T17:00:00+05:30")
+	req.OptionalIds = []string{"user3"}
+	booked, err := ScheduleEvent(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var move repository.RescheduleRequest
+	move.TimeRange.Start = "2025-08-09T15:00:00+05:30"
+	move.TimeRange.End = "2025-08-09T17:00:00+05:30"
+	if _, err := RescheduleMeeting(booked.MeetingID, move); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := CancelMeeting(booked.MeetingID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pending.Wait()
+	if len(mailer.sent) != 3 {
+		t.Fatalf("Expected an invitation, an update and a cancellation, got %d messages", len(mailer.sent))
+	}
+	invite, update, cancel := mailer.sent[0], mailer.sent[1], mailer.sent[2]
+
+	// The organizer (the first participant) is not mailed their own meeting
+	if strings.Join(invite.To, ",") != "bob@example.com,charlie@example.com" || invite.ReplyTo != `"Alice Johnson" <alice@example.com>` {
+		t.Errorf("Expected Bob and Charlie to be invited by Alice, got %v from %q", invite.To, invite.ReplyTo)
+	}
+	uid := "UID:" + booked.MeetingID + "@smart-scheduler\r\n"
+	for _, check := range []struct {
+		msg   notify.Message
+		lines []string
+	}{
+		{invite, []string{"METHOD:REQUEST", "STATUS:CONFIRMED", "DTSTART;TZID=Asia/Kolkata:20250809T093000",
+			"ORGANIZER;CN=Alice Johnson:mailto:alice@example.com",
+			"ATTENDEE;CN=Alice Johnson;ROLE=CHAIR;PARTSTAT=ACCEPTED:mailto:alice@example.com",
+			"ATTENDEE;CN=Charlie Brown;ROLE=OPT-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:charlie@example.com"}},
+		{update, []string{"METHOD:REQUEST", "SEQUENCE:1", "DTSTART;TZID=Asia/Kolkata:20250809T150000"}},
+		{cancel, []string{"METHOD:CANCEL", "SEQUENCE:2", "STATUS:CANCELLED"}},
+	} {
+		text := strings.ReplaceAll(string(check.msg.Calendar), "\r\n ", "") // unfold
+		if !strings.Contains(text, uid) {
+			t.Errorf("Expected %q in\n%s", uid, text)
+		}
+		for _, line := range check.lines {
+			if !strings.Contains(text, line) {
+				t.Errorf("Expected %q in\n%s", line, text)
+			}
+		}
+	}
+	if !strings.HasPrefix(update.Subject, "Updated invitation: Planning") || !strings.HasPrefix(cancel.Subject, "Cancelled: ") {
+		t.Errorf("Unexpected subjects %q and %q", update.Subject, cancel.Subject)
+	}
+}
+
+func TestInvitationWithoutOrganizerEmail(t *testing.T) {
+	memory := useMemoryStore(t)
+	SetMailer(nil, "Scheduler <noreply@example.com>")
+	t.Cleanup(func() { mailFrom = "Smart Scheduler <scheduler@localhost>" })
+
+	if err := memory.UpdateUserProfile("user1", "Asia/Kolkata", "", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Date(2025, 8, 9, 10, 0, 0, 0, getISTTimezone())
+	meeting := newMeeting("meeting-noemail", repository.ScheduleRequest{ParticipantIds: []string{"user1", "user2"}}, "Planning",
+		repository.Slot{Start: start, End: start.Add(time.Hour)}, "")
+	msg, err := invitation(meeting, noticeInvite)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text := string(msg.Calendar); !strings.Contains(text, "ORGANIZER;CN=Scheduler:mailto:noreply@example.com\r\n") {
+		t.Errorf("Expected the sender as organizer in\n%s", text)
+	}
+	if msg.ReplyTo != "" || strings.Join(msg.To, ",") != "bob@example.com" {
+		t.Errorf("Expected Bob invited without a Reply-To, got %v, %q", msg.To, msg.ReplyTo)
+	}
+}
+
+func TestRSVP(t *testing.T) {
+	useMemoryStore(t)
+
+	booked, err := ScheduleEvent(scheduleRequest([]string{"user1", "user2"}, "2025-08-09T09:00:00+05:30", "2025-08-09T17:00:00+05:30"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := RespondToMeeting(booked.MeetingID, repository.RSVPRequest{UserID: "user3", Status: "accepted"}); !errors.Is(err, ErrNotAttendee) {
+		t.Errorf("Expected ErrNotAttendee, got %v", err)
+	}
+	if _, err := RespondToMeeting(booked.MeetingID, repository.RSVPRequest{UserID: "user2", Status: "maybe"}); !errors.As(err, new(*apperr.ValidationError)) {
+		t.Error("Expected an error for an unknown status")
+	}
+	meeting, err := RespondToMeeting(booked.MeetingID, repository.RSVPRequest{UserID: "user2", Status: "Declined"})
+	if err != nil || meeting.Attendee("user2").Status != model.RSVPDeclined {
+		t.Fatalf("Expected user2 to have declined, got %+v, %v", meeting, err)
+	}
+
+	events, err := GetCalendarEvents("user2", "2025-08-09T00:00:00+05:30", "2025-08-10T00:00:00+05:30")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var found bool
+	for _, e := range events {
+		if e.EventCode == booked.MeetingID {
+			found = e.ResponseStatus == model.RSVPDeclined
+		}
+	}
+	if !found {
+		t.Errorf("Expected the calendar to show user2 declined the meeting, got %+v", events)
+	}
+
+	// The declined meeting only frees its slot when asked to
+	req := scheduleRequest([]string{"user2"}, booked.StartTime, booked.EndTime)
+	if _, err := ScheduleEvent(req); !errors.Is(err, ErrNoSlotFound) {
+		t.Errorf("Expected the declined meeting to be busy time by default, got %v", err)
+	}
+	req.IgnoreDeclined = true
+	if again, err := ScheduleEvent(req); err != nil || again.StartTime != booked.StartTime {
+		t.Errorf("Expected the declined slot to be booked, got %+v, %v", again, err)
+	}
+}
+
+func TestProcessReply(t *testing.T) {
+	useMemoryStore(t)
+
+	booked, err := ScheduleEvent(scheduleRequest([]string{"user1", "user2", "user3"}, "2025-08-09T16:00:00+05:30", "2025-08-09T17:00:00+05:30"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reply := func(partstat string, sequence int) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nMETHOD:REPLY\r\nBEGIN:VEVENT\r\n" +
+			"UID:" + booked.MeetingID + "@smart-scheduler\r\n" +
+			"SEQUENCE:" + strconv.Itoa(sequence) + "\r\n" +
+			"DTSTART:20250809T103000Z\r\n" +
+			"ATTENDEE;PARTSTAT=" + partstat + ":mailto:Bob@Example.com\r\n" +
+			"END:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+
+	updates, err := ProcessReply(strings.NewReader(reply("TENTATIVE", 0)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(updates) != 1 || updates[0] != (repository.RSVPUpdate{MeetingID: booked.MeetingID, UserID: "user2", Status: model.RSVPTentative}) {
+		t.Errorf("Unexpected updates %+v", updates)
+	}
+	meeting, _ := GetMeeting(booked.MeetingID)
+	if meeting.Attendee("user2").Status != model.RSVPTentative {
+		t.Errorf("Expected the reply to be stored, got %+v", meeting.Attendees)
+	}
+
+	if _, err := ProcessReply(strings.NewReader(reply("DELEGATED", 0))); !errors.Is(err, ErrInvalidReply) {
+		t.Errorf("Expected ErrInvalidReply for an unsupported PARTSTAT, got %v", err)
+	}
+	if _, err := ProcessReply(strings.NewReader(strings.Replace(reply("ACCEPTED", 0), "REPLY", "REQUEST", 1))); !errors.Is(err, ErrInvalidReply) {
+		t.Errorf("Expected ErrInvalidReply for a REQUEST, got %v", err)
+	}
+
+	// After a move, replies to the old time are stale
+	var move repository.RescheduleRequest
+	move.TimeRange.Start = booked.StartTime
+	move.TimeRange.End = booked.EndTime
+	if _, err := RescheduleMeeting(booked.MeetingID, move); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := ProcessReply(strings.NewReader(reply("DECLINED", 0))); !errors.Is(err, ErrOutdatedReply) {
+		t.Errorf("Expected ErrOutdatedReply, got %v", err)
+	}
+	if _, err := ProcessReply(strings.NewReader(reply("DECLINED", 1))); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestUserCRUD(t *testing.T) {
+	useMemoryStore(t)
+
+	req := repository.UserDetails{
+		UserCode:     "frank",
+		Name:         " Frank Ocean ",
+		Email:        "frank@example.com",
+		Timezone:     "Europe/Berlin",
+		WorkingHours: []repository.WorkingWindow{{Weekday: "monday", Start: "09:00", End: "17:00"}},
+	}
+	created, err := CreateUser(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created.Name != "Frank Ocean" || len(created.WorkingHours) != 1 {
+		t.Errorf("Unexpected user %+v", created)
+	}
+	if _, err := CreateUser(req); !errors.Is(err, ErrUserExists) {
+		t.Errorf("Expected ErrUserExists, got %v", err)
+	}
+	for _, tt := range []struct {
+		req  repository.UserDetails
+		path string
+	}{
+		{repository.UserDetails{UserCode: "bad/code", Name: "X"}, "userCode"},
+		{repository.UserDetails{UserCode: "x"}, "name"},
+		{repository.UserDetails{UserCode: "x", Name: "X", Email: "Frank <frank@example.com>"}, "email"},
+		{repository.UserDetails{UserCode: "x", Name: "X", Timezone: "Mars/Olympus"}, "timezone"},
+		{repository.UserDetails{UserCode: "x", Name: "X", WorkingHours: []repository.WorkingWindow{{Weekday: "funday", Start: "09:00", End: "17:00"}}}, "workingHours[0]"},
+	} {
+		var invalid *apperr.ValidationError
+		if _, err := CreateUser(tt.req); !errors.As(err, &invalid) || len(invalid.Fields) != 1 || invalid.Fields[0].Path != tt.path {
+			t.Errorf("Expected a field error on %s, got %v", tt.path, err)
+		}
+	}
+
+	// Names containing "o", by user code: frank, user1, user2, user3, user5
+	page, err := ListUsers("O", 2, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if page.Total != 5 || len(page.Users) != 2 || page.Users[0].UserCode != "user2" || page.Users[1].UserCode != "user3" {
+		t.Errorf("Expected the second page of five matches, got %+v", page)
+	}
+	var invalid *apperr.ValidationError
+	if _, err := ListUsers("", 0, 101); !errors.As(err, &invalid) || len(invalid.Fields) != 2 {
+		t.Errorf("Expected field errors for page 0 and a page size of 101, got %v", err)
+	}
+
+	name := "Frank O."
+	if updated, err := UpdateUser("frank", repository.UserUpdateRequest{Name: &name}); err != nil || updated.Name != name || updated.Email != req.Email {
+		t.Errorf("Expected only the name to change, got %+v, %v", updated, err)
+	}
+	if _, err := UpdateUser("nobody", repository.UserUpdateRequest{Name: &name}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	// Deleting a user takes them off their meetings, and off the ones they
+	// organized
+	booked, err := ScheduleEvent(scheduleRequest([]string{"frank", "user1"}, "2025-08-11T09:00:00+05:30", "2025-08-11T17:00:00+05:30"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := DeleteUser("frank"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := DeleteUser("frank"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+	if meeting, err := GetMeeting(booked.MeetingID); err != nil || len(meeting.Attendees) != 1 || meeting.OrganizerID != "" {
+		t.Errorf("Expected the meeting to keep only user1 and no organizer, got %+v, %v", meeting, err)
+	}
+	if _, err := GetCalendarEvents("frank", "", ""); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for the calendar, got %v", err)
+	}
+	for _, bounds := range [][2]string{{"soon", ""}, {"", "later"}, {"2025-08-11T17:00:00+05:30", "2025-08-11T09:00:00+05:30"}} {
+		if _, err := GetCalendarEvents("user1", bounds[0], bounds[1]); !errors.As(err, &invalid) || len(invalid.Fields) != 1 {
+			t.Errorf("Expected a field error for the range %v, got %v", bounds, err)
+		}
+	}
+	if _, err := ScheduleEvent(scheduleRequest([]string{"user1", "frank"}, "2025-08-11T09:00:00+05:30", "2025-08-11T17:00:00+05:30")); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound when scheduling, got %v", err)
+	}
+}
+
+// staleLookups finds no users or resources, as if another request created
+// them after the lookup.
+type staleLookups struct {
+	repository.Store
+}
+
+func (s staleLookups) Transaction(fn func(tx repository.Store) error) error {
+	return s.Store.Transaction(func(tx repository.Store) error { return fn(staleLookups{tx}) })
+}
+
+func (staleLookups) FindUser(string) (*model.User, error) { return nil, repository.ErrNotFound }
+
+func (staleLookups) FindResource(string) (*model.Resource, error) {
+	return nil, repository.ErrNotFound
+}
+
+func TestCreateConflictsAfterLookup(t *testing.T) {
+	SetStore(staleLookups{useMemoryStore(t)})
+
+	if _, err := CreateUser(repository.UserDetails{UserCode: "user1", Name: "Copy", Timezone: "UTC"}); !errors.Is(err, ErrUserExists) {
+		t.Errorf("Expected ErrUserExists, got %v", err)
+	}
+	if _, err := CreateResource(repository.ResourceDetails{ResourceCode: "room2", Name: "Copy"}); !errors.Is(err, ErrResourceExists) {
+		t.Errorf("Expected ErrResourceExists, got %v", err)
+	}
+}
+
+func TestResourceBooking(t *testing.T) {
+	useMemoryStore(t)
+
+	// The huddle room is the smallest room for three with video
+	req := scheduleRequest([]string{"user1", "user2"}, "2025-08-09T09:00:00+05:30", "2025-08-09T17:00:00+05:30")
+	req.Resource = &repository.ResourceRequirement{MinCapacity: 3, Features: []string{"Video"}}
+	resp, err := ScheduleEvent(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StartTime != "2025-08-09T09:30:00+05:30" || len(resp.ResourceIds) != 1 || resp.ResourceIds[0] != "room2" || resp.Location != "Huddle Room (Floor 2)" {
+		t.Errorf("Expected room2 booked at 09:30, got %+v", resp)
+	}
+	calendar, err := GetResourceCalendar("room2", "2025-08-09T00:00:00+05:30", "2025-08-10T00:00:00+05:30")
+	if err != nil || len(calendar) != 1 || calendar[0].Meeting.MeetingCode != resp.MeetingID {
+		t.Errorf("Expected the meeting on room2's calendar, got %+v, %v", calendar, err)
+	}
+
+	// While room2 is taken only the board room matches
+	other := scheduleRequest([]string{"user4"}, "2025-08-09T10:00:00+05:30", "2025-08-09T11:00:00+05:30")
+	other.ResourceIds = []string{"room2"}
+	if _, err := ScheduleEvent(other); err == nil {
+		t.Error("Expected no slot while room2 is booked")
+	}
+	other.ResourceIds = nil
+	other.Resource = req.Resource
+	if booked, err := ScheduleEvent(other); err != nil || len(booked.ResourceIds) != 1 || booked.ResourceIds[0] != "room1" {
+		t.Errorf("Expected room1, got %+v, %v", booked, err)
+	}
+
+	// Rescheduling keeps the room, which must be free at the new time
+	var move repository.RescheduleRequest
+	move.TimeRange.Start = "2025-08-09T14:30:00+05:30"
+	move.TimeRange.End = "2025-08-09T17:00:00+05:30"
+	moved, err := RescheduleMeeting(resp.MeetingID, move)
+	if err != nil || len(moved.ResourceIds) != 1 || moved.ResourceIds[0] != "room2" {
+		t.Errorf("Expected the meeting moved with room2, got %+v, %v", moved, err)
+	}
+
+	for name, invalid := range map[string]func(*repository.ScheduleRequest){
+		"unknown resource": func(r *repository.ScheduleRequest) { r.ResourceIds = []string{"room9"} },
+		"no match":         func(r *repository.ScheduleRequest) { r.Resource = &repository.ResourceRequirement{MinCapacity: 50} },
+		"recurring": func(r *repository.ScheduleRequest) {
+			r.ResourceIds, r.Recurrence = []string{"room3"}, "FREQ=WEEKLY;COUNT=2"
+		},
+		"hold": func(r *repository.ScheduleRequest) { r.ResourceIds, r.HoldMinutes = []string{"room3"}, 15 },
+	} {
+		r := scheduleRequest([]string{"user3"}, "2025-08-11T09:00:00+05:30", "2025-08-11T17:00:00+05:30")
+		invalid(&r)
+		if _, err := ScheduleEvent(r); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+
+	if _, err := CreateResource(repository.ResourceDetails{ResourceCode: "room2", Name: "Copy"}); !errors.Is(err, ErrResourceExists) {
+		t.Errorf("Expected ErrResourceExists, got %v", err)
+	}
+	created, err := CreateResource(repository.ResourceDetails{ResourceCode: "van", Name: "Van", Type: "Vehicle", Features: []string{" GPS ", "gps"}})
+	if err != nil || created.Type != "vehicle" || len(created.Features) != 1 || created.Features[0] != "gps" {
+		t.Errorf("Expected a normalised vehicle, got %+v, %v", created, err)
+	}
+	if rooms, _ := ListResources("room"); len(rooms) != 3 {
+		t.Errorf("Expected the three dummy rooms, got %d", len(rooms))
+	}
+
+	if err := DeleteResource("room2"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if meeting, _ := GetMeeting(resp.MeetingID); len(meeting.Resources) != 0 {
+		t.Errorf("Expected the booking to go with the room, got %+v", meeting.Resources)
+	}
+	if _, err := GetResourceCalendar("room2", "2025-08-09T00:00:00+05:30", "2025-08-10T00:00:00+05:30"); !errors.Is(err, ErrResourceNotFound) {
+		t.Errorf("Expected ErrResourceNotFound, got %v", err)
+	}
+}
+
+func TestBookMeetingRechecksConflicts(t *testing.T) {
+	memory := useMemoryStore(t)
+
+	ist := getISTTimezone()
+	slot := repository.Slot{
+		Start: time.Date(2025, 8, 9, 11, 0, 0, 0, ist),
+		End:   time.Date(2025, 8, 9, 12, 0, 0, 0, ist),
+	}
+	// user2's client call runs until 11:45
+	meeting := newMeeting("meeting-race", repository.ScheduleRequest{ParticipantIds: []string{"user1", "user2"}}, "Race", slot, "")
+	err := memory.Transaction(func(tx repository.Store) error {
+		return bookMeeting(tx, meeting, bookingCheck{})
+	})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.UserID != "user2" {
+		t.Fatalf("Expected a conflict naming user2, got %v", err)
+	}
+	if _, err := GetMeeting("meeting-race"); !errors.Is(err, ErrMeetingNotFound) {
+		t.Error("A conflicting booking must not be stored")
+	}
+}
+
+func TestUpdateEventOccurrence(t *testing.T) {
+	memory := useMemoryStore(t)
+
+	events, err := memory.UserEvents("user2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var series model.Event
+	for _, e := range events {
+		if e.EventCode == "series2" {
+			series = e
+		}
+	}
+
+	// Move the 11 August occurrence of the weekly 1:1 to the afternoon
+	occurrence := "2025-08-11T10:00:00+05:30"
+	newStart := "2025-08-11T15:00:00+05:30"
+	_, err = UpdateEvent(series.ID, repository.EventUpdateRequest{
+		Scope:      repository.ScopeThis,
+		Occurrence: occurrence,
+		StartTime:  &newStart,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	week, err := GetCalendarEvents("user2", "2025-08-11T00:00:00+05:30", "2025-08-19T00:00:00+05:30")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var starts []string
+	for _, e := range week {
+		if e.Title == "Weekly 1:1" {
+			starts = append(starts, e.StartTime.In(getISTTimezone()).Format(time.RFC3339))
+		}
+	}
+	if len(starts) != 2 || starts[0] != newStart || starts[1] != "2025-08-18T10:00:00+05:30" {
+		t.Errorf("Expected the moved and the following occurrence, got %v", starts)
+	}
+
+	// The same occurrence written in UTC is stored in the series' zone
+	renamedDay := "Moved 1:1"
+	override, err := UpdateEvent(series.ID, repository.EventUpdateRequest{
+		Scope:      repository.ScopeThis,
+		Occurrence: "2025-08-18T04:30:00Z",
+		Title:      &renamedDay,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if id := override.RecurrenceID; id == nil || id.Format(time.RFC3339) != "2025-08-18T10:00:00+05:30" {
+		t.Errorf("Expected the occurrence in the series' zone, got %v", id)
+	}
+
+	// Splitting the series at the overridden occurrence must not clash with
+	// the override's code
+	renamed := "Weekly sync"
+	tail, err := UpdateEvent(series.ID, repository.EventUpdateRequest{
+		Scope:      repository.ScopeFollowing,
+		Occurrence: occurrence,
+		Title:      &renamed,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tail.ID == series.ID || tail.Title != renamed {
+		t.Errorf("Expected a new series from the occurrence, got %+v", tail)
+	}
+	week, err = GetCalendarEvents("user2", "2025-08-11T00:00:00+05:30", "2025-08-19T00:00:00+05:30")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	starts = nil
+	for _, e := range week {
+		if e.RecurringEventID != nil && *e.RecurringEventID == tail.ID {
+			starts = append(starts, e.StartTime.In(getISTTimezone()).Format(time.RFC3339))
+		}
+	}
+	if len(starts) != 2 || starts[0] != newStart || starts[1] != "2025-08-18T10:00:00+05:30" {
+		t.Errorf("Expected the override to follow the new series, got %v", starts)
+	}
+}
+
+func TestUpdateFollowingMovesWeekday(t *testing.T) {
+	memory := useMemoryStore(t)
+
+	events, err := memory.UserEvents("user2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var series model.Event
+	for _, e := range events {
+		if e.EventCode == "series2" {
+			series = e
+		}
+	}
+
+	// Move the Monday 1:1 to Tuesdays from 11 August on
+	newStart := "2025-08-12T10:00:00+05:30"
+	tail, err := UpdateEvent(series.ID, repository.EventUpdateRequest{
+		Scope:      repository.ScopeFollowing,
+		Occurrence: "2025-08-11T10:00:00+05:30",
+		StartTime:  &newStart,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(tail.RRule, "BYDAY=TU") {
+		t.Errorf("Expected the new series on Tuesdays, got %s", tail.RRule)
+	}
+
+	weeks, err := GetCalendarEvents("user2", "2025-08-04T00:00:00+05:30", "2025-08-25T00:00:00+05:30")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var starts []string
+	for _, e := range weeks {
+		if e.Title == "Weekly 1:1" {
+			starts = append(starts, e.StartTime.In(getISTTimezone()).Format(time.RFC3339))
+		}
+	}
+	want := []string{"2025-08-04T10:00:00+05:30", "2025-08-12T10:00:00+05:30", "2025-08-19T10:00:00+05:30"}
+	if strings.Join(starts, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, starts)
+	}
+
+	// Mondays are no longer occurrences of the new series
+	_, err = UpdateEvent(tail.ID, repository.EventUpdateRequest{Scope: repository.ScopeThis, Occurrence: "2025-08-18T10:00:00+05:30", StartTime: &newStart})
+	if kind, _ := apperr.Of(err); !errors.Is(err, ErrInvalidOccurrence) || kind.Status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected a 422 for a Monday, got %v", err)
+	}
+}
+
+func TestRescheduleRecurringMeetingKeepsSearchedRule(t *testing.T) {
+	memory := useMemoryStore(t)
+
+	// An open-ended weekly meeting, stored before series were capped
+	start := time.Date(2025, 8, 11, 9, 0, 0, 0, getISTTimezone())
+	meeting := newMeeting("meeting-weekly", repository.ScheduleRequest{ParticipantIds: []string{"user3", "user4"}}, "Review",
+		repository.Slot{Start: start, End: start.Add(30 * time.Minute)}, "FREQ=WEEKLY")
+	if err := memory.CreateMeeting(meeting); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var move repository.RescheduleRequest
+	move.TimeRange.Start = "2025-08-12T09:00:00+05:30"
+	move.TimeRange.End = "2025-08-12T17:00:00+05:30"
+	moved, err := RescheduleMeeting(meeting.MeetingCode, move)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "FREQ=WEEKLY;COUNT=" + strconv.Itoa(maxMeetingOccurrences)
+	if moved.Recurrence != want {
+		t.Errorf("Expected the searched rule %s, got %s", want, moved.Recurrence)
+	}
+	if stored, _ := GetMeeting(meeting.MeetingCode); stored == nil || stored.RRule != want {
+		t.Errorf("Expected the searched rule to be stored, got %+v", stored)
+	}
+}
+
+func TestScheduleRecurringMeetingByDay(t *testing.T) {
+	useMemoryStore(t)
+
+	// The range starts on a Tuesday, but the series only meets on Mondays
+	req := scheduleRequest([]string{"user3"}, "2025-08-12T09:00:00+05:30", "2025-08-19T17:00:00+05:30")
+	req.Recurrence = "FREQ=WEEKLY;BYDAY=MO;COUNT=4"
+	resp, err := ScheduleEvent(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start, _ := time.Parse(time.RFC3339, resp.StartTime)
+	if start.Weekday() != time.Monday || !strings.HasPrefix(resp.StartTime, "2025-08-18T") {
+		t.Errorf("Expected the series to start on Monday 18 August, got %s", resp.StartTime)
+	}
+}
+
+func TestFreeBusy(t *testing.T) {
+	useMemoryStore(t)
+
+	var req repository.FreeBusyRequest
+	req.UserIds = []string{"user1", "user2"}
+	req.TimeRange.Start = "2025-08-09T09:00:00+05:30"
+	req.TimeRange.End = "2025-08-09T13:00:00+05:30"
+	resp, err := FreeBusy(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	user1 := []repository.Interval{
+		{Start: "2025-08-09T09:00:00+05:30", End: "2025-08-09T09:30:00+05:30"},
+		{Start: "2025-08-09T12:00:00+05:30", End: "2025-08-09T13:00:00+05:30"},
+	}
+	if !equalIntervals(resp.Busy["user1"], user1) {
+		t.Errorf("Expected user1 busy %v, got %v", user1, resp.Busy["user1"])
+	}
+	free := []repository.Interval{
+		{Start: "2025-08-09T09:30:00+05:30", End: "2025-08-09T11:00:00+05:30"},
+		{Start: "2025-08-09T11:45:00+05:30", End: "2025-08-09T12:00:00+05:30"},
+	}
+	if !equalIntervals(resp.Free, free) {
+		t.Errorf("Expected group free %v, got %v", free, resp.Free)
+	}
+
+	req.UserIds = []string{"user1", "nobody"}
+	if _, err := FreeBusy(req); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for an unknown user, got %v", err)
+	}
+
+	req.UserIds = []string{"user1", "user1"}
+	req.TimeRange.End = "soon"
+	var invalid *apperr.ValidationError
+	if _, err := FreeBusy(req); !errors.As(err, &invalid) || len(invalid.Fields) != 2 {
+		t.Errorf("Expected the repeated user and the bad end as field errors, got %v", err)
+	}
+}
+
+func TestUserEvents(t *testing.T) {
+	memory := useMemoryStore(t)
+
+	eventRequest := func(title, start, end string) repository.EventRequest {
+		return repository.EventRequest{Title: title, StartTime: "2025-08-09T" + start + ":00+05:30", EndTime: "2025-08-09T" + end + ":00+05:30"}
+	}
+
+	// Overlapping the standup is allowed with a warning by default
+	focus, err := CreateUserEvent("user1", eventRequest("Focus time", "09:15", "10:00"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(focus.Overlaps) != 1 || focus.Overlaps[0].Title != "Team Standup" || focus.Overlaps[0].EventID == 0 {
+		t.Errorf("Expected a warning about the standup, got %+v", focus.Overlaps)
+	}
+
+	travel := eventRequest("Travel", "12:30", "13:30")
+	travel.OnOverlap = repository.OverlapReject
+	var overlap *OverlapError
+	if _, err := CreateUserEvent("user1", travel); !errors.As(err, &overlap) || overlap.Overlaps[0].Title != "Project Review" {
+		t.Errorf("Expected an OverlapError for the project review, got %v", err)
+	}
+	travel.Transparent = true
+	if resp, err := CreateUserEvent("user1", travel); err != nil || len(resp.Overlaps) != 0 {
+		t.Errorf("Expected a transparent event to skip the overlap check, got %+v, %v", resp, err)
+	}
+
+	for _, tt := range []struct {
+		req   repository.EventRequest
+		paths []string
+	}{
+		{eventRequest("", "10:00", "11:00"), []string{"title"}},
+		{eventRequest("Backwards", "11:00", "10:00"), []string{"endTime"}},
+		{repository.EventRequest{StartTime: "soon"}, []string{"title", "startTime", "endTime"}},
+		{repository.EventRequest{Title: "Bad option", StartTime: "2025-08-09T10:00:00+05:30", EndTime: "2025-08-09T11:00:00+05:30", OnOverlap: "ignore"}, []string{"onOverlap"}},
+	} {
+		var invalid *apperr.ValidationError
+		if _, err := CreateUserEvent("user1", tt.req); !errors.As(err, &invalid) || len(invalid.Fields) != len(tt.paths) {
+			t.Errorf("Expected field errors for %v, got %v", tt.paths, err)
+			continue
+		}
+		for i, path := range tt.paths {
+			if invalid.Fields[i].Path != path {
+				t.Errorf("Expected field error %d on %s, got %+v", i, path, invalid.Fields[i])
+			}
+		}
+	}
+	if _, err := CreateUserEvent("nobody", eventRequest("Focus time", "10:00", "11:00")); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	// Opaque events are busy time, transparent ones are not
+	offsite := eventRequest("Offsite", "10:00", "11:00")
+	offsite.Transparent = true
+	if _, err := CreateUserEvent("user1", offsite); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var req repository.FreeBusyRequest
+	req.UserIds = []string{"user1"}
+	req.TimeRange.Start = "2025-08-09T09:00:00+05:30"
+	req.TimeRange.End = "2025-08-09T13:00:00+05:30"
+	resp, err := FreeBusy(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	busy := []repository.Interval{
+		{Start: "2025-08-09T09:00:00+05:30", End: "2025-08-09T10:00:00+05:30"},
+		{Start: "2025-08-09T12:00:00+05:30", End: "2025-08-09T13:00:00+05:30"},
+	}
+	if !equalIntervals(resp.Busy["user1"], busy) {
+		t.Errorf("Expected user1 busy %v, got %v", busy, resp.Busy["user1"])
+	}
+
+	// Moving the block clear of the standup leaves nothing to warn about
+	start, end := "2025-08-09T10:30:00+05:30", "2025-08-09T11:30:00+05:30"
+	moved, err := UpdateUserEvent("user1", focus.ID, repository.UserEventUpdateRequest{StartTime: &start, EndTime: &end, OnOverlap: repository.OverlapReject})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if moved.StartTime.Format(time.RFC3339) != start || moved.Title != "Focus time" || len(moved.Overlaps) != 0 {
+		t.Errorf("Expected the block moved without overlaps, got %+v", moved)
+	}
+	blank, early := " ", "2025-08-09T10:00:00+05:30"
+	for _, update := range []repository.UserEventUpdateRequest{{Title: &blank}, {EndTime: &early}} {
+		var invalid *apperr.ValidationError
+		if _, err := UpdateUserEvent("user1", focus.ID, update); !errors.As(err, &invalid) || len(invalid.Fields) != 1 {
+			t.Errorf("Expected one field error for %+v, got %v", update, err)
+		}
+	}
+	if _, err := UpdateUserEvent("user2", focus.ID, repository.UserEventUpdateRequest{StartTime: &start}); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Expected another user's event to be not found, got %v", err)
+	}
+	series, _ := GetCalendarEvents("user5", "2025-08-09T09:00:00+05:30", "2025-08-09T10:00:00+05:30")
+	if _, err := UpdateUserEvent("user5", *series[0].RecurringEventID, repository.UserEventUpdateRequest{StartTime: &start}); !errors.Is(err, ErrRecurringEvent) {
+		t.Errorf("Expected ErrRecurringEvent, got %v", err)
+	}
+
+	if err := DeleteUserEvent("user5", *series[0].RecurringEventID); !errors.Is(err, ErrRecurringEvent) {
+		t.Errorf("Expected ErrRecurringEvent, got %v", err)
+	}
+
+	// Tentative holds are busy time too
+	holdStart, _ := time.Parse(time.RFC3339, "2025-08-09T15:00:00+05:30")
+	if err := memory.CreateHold(&model.Hold{HoldCode: "hold-1", UserID: "user1", Title: "Planning", StartTime: holdStart, EndTime: holdStart.Add(time.Hour), ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	held, err := CreateUserEvent("user1", eventRequest("Gym", "15:30", "16:30"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(held.Overlaps) != 1 || held.Overlaps[0].HoldID != "hold-1" {
+		t.Errorf("Expected a warning about the hold, got %+v", held.Overlaps)
+	}
+
+	if err := DeleteUserEvent("user1", focus.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := DeleteUserEvent("user1", focus.ID); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Expected ErrEventNotFound, got %v", err)
+	}
+}
+
+func equalIntervals(a, b []repository.Interval) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestExportCalendar(t *testing.T) {
+	memory := useMemoryStore(t)
+
+	// Move one occurrence of the weekly 1:1 so it is exported as an override
+	events, _ := memory.UserEvents("user2")
+	var series model.Event
+	for _, e := range events {
+		if e.EventCode == "series2" {
+			series = e
+		}
+	}
+	newStart := "2025-08-11T15:00:00+05:30"
+	_, err := UpdateEvent(series.ID, repository.EventUpdateRequest{
+		Scope:      repository.ScopeThis,
+		Occurrence: "2025-08-11T10:00:00+05:30",
+		StartTime:  &newStart,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	booked, err := ScheduleEvent(scheduleRequest([]string{"user1", "user2"}, "2025-08-09T09:00:00+05:30", "2025-08-09T17:00:00+05:30"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cal, err := ExportCalendar("user2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var out bytes.Buffer
+	if err := cal.Encode(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text := out.String()
+	for _, line := range []string{
+		"X-WR-CALNAME:Bob Smith",
+		"TZID:Asia/Kolkata",
+		"UID:event3@smart-scheduler",
+		"UID:" + booked.MeetingID + "@smart-scheduler",
+		"DTSTART;TZID=Asia/Kolkata:20250804T100000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"RECURRENCE-ID;TZID=Asia/Kolkata:20250811T100000",
+		"DTSTART;TZID=Asia/Kolkata:20250811T150000",
+	} {
+		if !strings.Contains(text, line+"\r\n") {
+			t.Errorf("Expected %q in\n%s", line, text)
+		}
+	}
+	if n := strings.Count(text, "UID:series2@smart-scheduler"); n != 2 {
+		t.Errorf("Expected the series and its override to share a UID, got %d", n)
+	}
+
+	if _, err := ExportCalendar("nobody"); !errors.Is(err, ErrUserNotFound) || !strings.Contains(err.Error(), "nobody") {
+		t.Errorf("Expected ErrUserNotFound naming the user, got %v", err)
+	}
+}
+
+func TestImportCalendar(t *testing.T) {
+	useMemoryStore(t)
+
+	feed := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:offsite@example.com\r\nDTSTART;TZID=Asia/Kolkata:20250809T100000\r\nDTEND;TZID=Asia/Kolkata:20250809T110000\r\nSUMMARY:Offsite\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:gym@example.com\r\nDTSTART;TZID=Asia/Kolkata:20250811T070000\r\nDURATION:PT1H\r\nRRULE:FREQ=DAILY;COUNT=5\r\nSUMMARY:Gym\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:gym@example.com\r\nRECURRENCE-ID;TZID=Asia/Kolkata:20250812T070000\r\nDTSTART;TZID=Asia/Kolkata:20250812T180000\r\nDURATION:PT1H\r\nSUMMARY:Gym\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:party@example.com\r\nDTSTART;TZID=Asia/Kolkata:20250809T190000\r\nRRULE:FREQ=DAILY;BYMONTHDAY=1\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	result, err := ImportCalendar("user1", "work", strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Created != 3 || len(result.Skipped) != 1 || result.Skipped[0].UID != "party@example.com" {
+		t.Errorf("Expected 3 events created and the unsupported rule skipped, got %+v", result)
+	}
+
+	// Importing the same feed again changes nothing
+	again, err := ImportCalendar("user1", "work", strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if again.Created != 0 || again.Updated != 0 || again.Unchanged != 3 {
+		t.Errorf("Expected a re-import to leave everything unchanged, got %+v", again)
+	}
+
+	// Imported events are changed by importing again, not through the API
+	imported, _ := GetCalendarEvents("user1", "2025-08-09T10:00:00+05:30", "2025-08-09T11:00:00+05:30")
+	var offsiteId uint
+	for _, e := range imported {
+		if e.Title == "Offsite" {
+			offsiteId = e.ID
+		}
+	}
+	title := "Renamed"
+	if _, err := UpdateEvent(offsiteId, repository.EventUpdateRequest{Title: &title}); !errors.Is(err, ErrImportedEvent) {
+		t.Errorf("Expected ErrImportedEvent when editing, got %v", err)
+	}
+	if err := DeleteEvent(offsiteId, "", ""); !errors.Is(err, ErrImportedEvent) {
+		t.Errorf("Expected ErrImportedEvent when deleting, got %v", err)
+	}
+
+	// The imported events are busy time, the moved occurrence included
+	var req repository.FreeBusyRequest
+	req.UserIds = []string{"user1"}
+	req.TimeRange.Start = "2025-08-12T00:00:00+05:30"
+	req.TimeRange.End = "2025-08-13T00:00:00+05:30"
+	busy, err := FreeBusy(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b := busy.Busy["user1"]; len(b) != 1 || b[0].Start != "2025-08-12T18:00:00+05:30" {
+		t.Errorf("Expected only the moved gym session on 12 August, got %v", b)
+	}
+
+	// Cancellations remove the event, or the occurrence from its series
+	cancel := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nMETHOD:CANCEL\r\n" +
+		"BEGIN:VEVENT\r\nUID:offsite@example.com\r\nDTSTART;TZID=Asia/Kolkata:20250809T100000\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:gym@example.com\r\nRECURRENCE-ID;TZID=Asia/Kolkata:20250813T070000\r\nDTSTART;TZID=Asia/Kolkata:20250813T070000\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	cancelled, err := ImportCalendar("user1", "work", strings.NewReader(cancel))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cancelled.Removed != 2 {
+		t.Errorf("Expected 2 removals, got %+v", cancelled)
+	}
+	events, err := GetCalendarEvents("user1", "2025-08-09T00:00:00+05:30", "2025-08-16T00:00:00+05:30")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	gym := 0
+	for _, e := range events {
+		if e.Title == "Offsite" {
+			t.Error("Expected the cancelled offsite to be removed")
+		}
+		if e.Title == "Gym" {
+			gym++
+		}
+	}
+	if gym != 4 {
+		t.Errorf("Expected 4 gym sessions after cancelling one, got %d", gym)
+	}
+
+	// A whole feed drops what it no longer lists and keeps transparent events
+	// without blocking time
+	trimmed := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:gym@example.com\r\nDTSTART;TZID=Asia/Kolkata:20250811T070000\r\nDURATION:PT1H\r\nRRULE:FREQ=DAILY;COUNT=5\r\nSUMMARY:Gym\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:holiday@example.com\r\nDTSTART;TZID=Asia/Kolkata:20250812T090000\r\nDTEND;TZID=Asia/Kolkata:20250812T170000\r\nTRANSP:TRANSPARENT\r\nSUMMARY:Holiday\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	pruned, err := ImportCalendar("user1", "work", strings.NewReader(trimmed))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pruned.Created != 1 || pruned.Updated != 1 || pruned.Removed != 1 {
+		t.Errorf("Expected the holiday created, the series updated and its override removed, got %+v", pruned)
+	}
+	busy, err = FreeBusy(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b := busy.Busy["user1"]; len(b) != 1 || b[0].Start != "2025-08-12T07:00:00+05:30" {
+		t.Errorf("Expected only the gym session at 07:00 on 12 August, got %v", b)
+	}
+	events, _ = GetCalendarEvents("user1", "2025-08-12T00:00:00+05:30", "2025-08-13T00:00:00+05:30")
+	holiday := false
+	for _, e := range events {
+		holiday = holiday || (e.Title == "Holiday" && e.Transparent)
+	}
+	if !holiday {
+		t.Error("Expected the holiday on the calendar as a transparent event")
+	}
+
+	// The same UID from another source is a separate event
+	other, err := ImportCalendar("user1", "personal", strings.NewReader(feed))
+	if err != nil || other.Created != 3 {
+		t.Errorf("Expected another source to create its own events, got %+v, %v", other, err)
+	}
+	if _, err := ImportCalendar("nobody", "", strings.NewReader(feed)); !errors.Is(err, ErrUserNotFound) || !strings.Contains(err.Error(), "nobody") {
+		t.Errorf("Expected ErrUserNotFound naming the user, got %v", err)
+	}
+}
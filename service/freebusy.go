@@ -0,0 +1,110 @@
+package service
+
+import (
+	"smart-scheduler/apperr"
+	"smart-scheduler/repository"
+	"sort"
+	"time"
+)
+
+// FreeBusy reports when each user is busy in the requested range and when
+// the whole group is free. Only times are returned, never event titles.
+func FreeBusy(req repository.FreeBusyRequest) (*repository.FreeBusyResponse, error) {
+	var v validator
+	if len(req.UserIds) == 0 {
+		v.add("userIDs", apperr.CodeRequired, "must list at least one user")
+	}
+	v.ids("userIDs", req.UserIds)
+	start, startOK := v.time("timeRange.start", req.TimeRange.Start)
+	end, endOK := v.time("timeRange.end", req.TimeRange.End)
+	if startOK && endOK && !start.Before(end) {
+		v.add("timeRange.end", apperr.CodeOutOfRange, "must be after timeRange.start")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if err := requireUsers(req.UserIds); err != nil {
+		return nil, err
+	}
+
+	eventMap, err := loadBusy(req.UserIds, start, end, bookingCheck{ignoreDeclined: req.IgnoreDeclined})
+	if err != nil {
+		return nil, err
+	}
+
+	window := repository.Slot{Start: start, End: end}
+	resp := &repository.FreeBusyResponse{
+		Start: req.TimeRange.Start,
+		End:   req.TimeRange.End,
+		Busy:  make(map[string][]repository.Interval, len(req.UserIds)),
+	}
+	var groupBusy []repository.Slot
+	for _, userId := range req.UserIds {
+		merged := mergeIntervals(eventMap[userId], window)
+		resp.Busy[userId] = toIntervals(merged, start.Location())
+		groupBusy = append(groupBusy, merged...)
+	}
+	resp.Free = toIntervals(freeIntervals(mergeIntervals(groupBusy, window), window), start.Location())
+	return resp, nil
+}
+
+// mergeIntervals clips busy to window and merges the intervals that overlap
+// or touch, returning them in start order.
+func mergeIntervals(busy []repository.Slot, window repository.Slot) []repository.Slot {
+	var clipped []repository.Slot
+	for _, b := range busy {
+		if !b.Overlaps(window) {
+			continue
+		}
+		if b.Start.Before(window.Start) {
+			b.Start = window.Start
+		}
+		if b.End.After(window.End) {
+			b.End = window.End
+		}
+		clipped = append(clipped, b)
+	}
+	sort.Slice(clipped, func(i, j int) bool { return clipped[i].Start.Before(clipped[j].Start) })
+
+	var merged []repository.Slot
+	for _, b := range clipped {
+		if n := len(merged); n > 0 && !b.Start.After(merged[n-1].End) {
+			if b.End.After(merged[n-1].End) {
+				merged[n-1].End = b.End
+			}
+			continue
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
+
+// freeIntervals returns the gaps in window between merged busy intervals.
+func freeIntervals(merged []repository.Slot, window repository.Slot) []repository.Slot {
+	var free []repository.Slot
+	cursor := window.Start
+	for _, b := range merged {
+		if b.Start.After(cursor) {
+			free = append(free, repository.Slot{Start: cursor, End: b.Start})
+		}
+		if b.End.After(cursor) {
+			cursor = b.End
+		}
+	}
+	if cursor.Before(window.End) {
+		free = append(free, repository.Slot{Start: cursor, End: window.End})
+	}
+	return free
+}
+
+func toIntervals(slots []repository.Slot, loc *time.Location) []repository.Interval {
+	intervals := make([]repository.Interval, 0, len(slots))
+	for _, s := range slots {
+		intervals = append(intervals, repository.Interval{
+			Start: s.Start.In(loc).Format(time.RFC3339),
+			End:   s.End.In(loc).Format(time.RFC3339),
+		})
+	}
+	return intervals
+}
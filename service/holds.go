@@ -0,0 +1,159 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
+	"smart-scheduler/repository"
+	"time"
+)
+
+var ErrHoldNotFound = apperr.New(apperr.Kind{Code: "hold_not_found", Title: "Hold not found", Status: http.StatusNotFound}, "hold not found or already expired")
+
+// placeHolds reserves the chosen slot on the calendar of every required
+// attendee, and every optional attendee who can make it, for
+// req.HoldMinutes. The holds count as busy time until they are confirmed,
+// released or swept after expiry.
+func placeHolds(req repository.ScheduleRequest, title string, slot scoredSlot) (*repository.ScheduledMeetingResponse, error) {
+	holdCode := newCode("hold")
+	expiresAt := time.Now().Add(time.Duration(req.HoldMinutes) * time.Minute)
+
+	var optional []string
+	for _, userId := range req.OptionalIds {
+		if !contains(slot.unavailable, userId) {
+			optional = append(optional, userId)
+		}
+	}
+
+	err := store.Transaction(func(tx repository.Store) error {
+		if err := reserve(tx, req.ParticipantIds, slot.Start, slot.End, "", searchCheck(req)); err != nil {
+			return err
+		}
+		for _, userId := range append(append([]string{}, req.ParticipantIds...), optional...) {
+			err := tx.CreateHold(&model.Hold{
+				HoldCode:    holdCode,
+				UserID:      userId,
+				Optional:    contains(optional, userId),
+				Title:       title,
+				OrganizerID: req.OrganizerID,
+				Description: req.Description,
+				Location:    req.Location,
+				StartTime:   slot.Start,
+				EndTime:     slot.End,
+				ExpiresAt:   expiresAt,
+			})
+			if err != nil {
+				return storageFailure(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Placed hold %s for %d participants until %v", holdCode, len(req.ParticipantIds)+len(optional), expiresAt)
+
+	return &repository.ScheduledMeetingResponse{
+		Title:          title,
+		ParticipantIds: req.ParticipantIds,
+		OptionalIds:    optional,
+		UnavailableIds: slot.unavailable,
+		StartTime:      slot.Start.Format(time.RFC3339),
+		EndTime:        slot.End.Format(time.RFC3339),
+		Status:         repository.StatusHeld,
+		HoldID:         holdCode,
+		HoldExpiresAt:  expiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// ConfirmHold turns every active hold sharing holdCode into a real event.
+func ConfirmHold(holdCode string) (*repository.ScheduledMeetingResponse, error) {
+	var meeting *model.Meeting
+
+	err := store.Transaction(func(tx repository.Store) error {
+		holds, err := tx.ActiveHoldsByCode(holdCode, time.Now())
+		if err != nil {
+			return storageFailure(err)
+		}
+		if len(holds) == 0 {
+			return ErrHoldNotFound
+		}
+
+		first := holds[0]
+		slot := repository.Slot{Start: first.StartTime, End: first.EndTime}
+		req := repository.ScheduleRequest{
+			OrganizerID: first.OrganizerID,
+			Description: first.Description,
+			Location:    first.Location,
+		}
+		for _, h := range holds {
+			if h.Optional {
+				req.OptionalIds = append(req.OptionalIds, h.UserID)
+			} else {
+				req.ParticipantIds = append(req.ParticipantIds, h.UserID)
+			}
+		}
+
+		meetingCode := newCode("meeting")
+		meeting = newMeeting(meetingCode, req, first.Title, slot, "")
+		// A meeting someone declined cannot keep them from one they hold
+		check := bookingCheck{excludeHoldCode: holdCode, ignoreDeclined: true}
+		if err := bookMeeting(tx, meeting, check); err != nil {
+			return err
+		}
+		if _, err := tx.DeleteHolds(holdCode); err != nil {
+			return storageFailure(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Confirmed hold %s as meeting %s", holdCode, meeting.MeetingCode)
+	notifyAttendees(meeting, noticeInvite)
+	return meetingResponse(meeting), nil
+}
+
+// ReleaseHold drops every hold sharing holdCode without booking anything.
+func ReleaseHold(holdCode string) error {
+	deleted, err := store.DeleteHolds(holdCode)
+	if err != nil {
+		return storageFailure(err)
+	}
+	if deleted == 0 {
+		return ErrHoldNotFound
+	}
+	return nil
+}
+
+// ReleaseExpiredHolds deletes holds whose expiry has passed and returns how
+// many rows were removed.
+func ReleaseExpiredHolds(now time.Time) (int64, error) {
+	deleted, err := store.DeleteExpiredHolds(now)
+	return deleted, storageFailure(err)
+}
+
+// StartHoldSweeper releases expired holds every interval until stop is closed.
+func StartHoldSweeper(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				released, err := ReleaseExpiredHolds(now)
+				if err != nil {
+					log.Printf("Hold sweeper failed: %v", err)
+				} else if released > 0 {
+					log.Printf("Hold sweeper released %d expired holds", released)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"smart-scheduler/apperr"
+	"smart-scheduler/ical"
+	"smart-scheduler/model"
+	"smart-scheduler/recurrence"
+	"smart-scheduler/repository"
+	"sort"
+	"time"
+)
+
+// DefaultImportSource names the feed of imports that do not give one.
+const DefaultImportSource = "ics"
+
+var ErrInvalidCalendar = apperr.New(apperr.Kind{Code: "invalid_calendar", Title: "Invalid iCalendar data", Status: http.StatusBadRequest}, "invalid iCalendar data")
+
+// ImportCalendar reads iCalendar data into userId's calendar as busy time.
+// Events are matched by UID and RECURRENCE-ID among the ones imported from
+// the same source, so importing a feed again updates them in place. A
+// cancelled event removes its earlier copy; a cancelled occurrence becomes
+// an EXDATE of its series. Transparent events are kept but do not block
+// time. A whole feed, one without an iTIP method other than PUBLISH, also
+// removes the events it no longer lists.
+func ImportCalendar(userId, source string, r io.Reader) (*repository.ImportResult, error) {
+	if source == "" {
+		source = DefaultImportSource
+	}
+	user, err := findUser(store, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Floating times and all-day events are read in the user's zone
+	cal, invalid, err := ical.Decode(r, user.Location())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+	result := &repository.ImportResult{Source: source}
+	for _, e := range invalid {
+		result.Skipped = append(result.Skipped, repository.ImportSkip{UID: e.UID, Reason: e.Err.Error()})
+	}
+
+	// Series first, so their overridden occurrences can point at them
+	events := cal.Events
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].RecurrenceID == nil && events[j].RecurrenceID != nil
+	})
+
+	err = store.Transaction(func(tx repository.Store) error {
+		existing, err := tx.ImportedEvents(userId, source)
+		if err != nil {
+			return storageFailure(err)
+		}
+		imp := &importer{tx: tx, userId: userId, source: source, result: result, known: map[string]*model.Event{}, seen: map[string]bool{}, unread: map[string]bool{}}
+		for i := range existing {
+			imp.known[importKey(existing[i].UID, existing[i].RecurrenceID)] = &existing[i]
+		}
+		// Events the feed lists but we could not read keep their earlier copy
+		for _, e := range invalid {
+			imp.unread[e.UID] = true
+		}
+		for _, e := range events {
+			removed := cal.Method == "CANCEL" || e.Status == "CANCELLED"
+			if e.RecurrenceID != nil {
+				err = imp.occurrence(e, removed)
+			} else {
+				err = imp.event(e, removed)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if cal.Method == "" || cal.Method == "PUBLISH" {
+			return imp.prune()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type importer struct {
+	tx     repository.Store
+	userId string
+	source string
+	result *repository.ImportResult
+	known  map[string]*model.Event // by importKey
+	seen   map[string]bool         // importKeys in the feed
+	unread map[string]bool         // UIDs in the feed that were skipped
+}
+
+func importKey(uid string, recurrenceId *time.Time) string {
+	if recurrenceId == nil {
+		return uid
+	}
+	return uid + "|" + recurrenceId.UTC().Format(time.RFC3339)
+}
+
+// event imports a one-off event or a whole series.
+func (imp *importer) event(e ical.Event, removed bool) error {
+	if removed {
+		// The series goes with all of its overridden occurrences
+		deleted := false
+		for key, row := range imp.known {
+			if row.UID != e.UID {
+				continue
+			}
+			if err := imp.tx.DeleteEvent(row); err != nil {
+				return storageFailure(err)
+			}
+			delete(imp.known, key)
+			deleted = true
+		}
+		imp.count(deleted)
+		return nil
+	}
+
+	rrule := ""
+	if e.RRule != "" {
+		rule, err := recurrence.Parse(e.RRule, e.Start.Location())
+		if err != nil {
+			imp.skip(e.UID, err.Error())
+			imp.unread[e.UID] = true
+			return nil
+		}
+		rrule = rule.String()
+	}
+	return imp.upsert(e, rrule, nil)
+}
+
+// occurrence imports an overridden or cancelled occurrence of a series.
+func (imp *importer) occurrence(e ical.Event, removed bool) error {
+	master := imp.known[importKey(e.UID, nil)]
+	if master != nil && !master.IsRecurring() {
+		master = nil
+	}
+	if !removed {
+		var seriesId *uint
+		if master != nil {
+			seriesId = &master.ID
+		}
+		return imp.upsert(e, "", seriesId)
+	}
+
+	changed := false
+	key := importKey(e.UID, e.RecurrenceID)
+	if row := imp.known[key]; row != nil {
+		if err := imp.tx.DeleteEvent(row); err != nil {
+			return storageFailure(err)
+		}
+		delete(imp.known, key)
+		changed = true
+	}
+	if master != nil && !master.IsExcluded(*e.RecurrenceID) {
+		master.AddExDate(*e.RecurrenceID)
+		if err := imp.tx.SaveEvent(master); err != nil {
+			return storageFailure(err)
+		}
+		changed = true
+	}
+	imp.count(changed)
+	return nil
+}
+
+// upsert creates the event or updates its earlier copy when it changed.
+func (imp *importer) upsert(e ical.Event, rrule string, seriesId *uint) error {
+	fresh := model.Event{
+		UserID:           imp.userId,
+		Title:            e.Summary,
+		StartTime:        e.Start,
+		EndTime:          e.End,
+		RRule:            rrule,
+		RecurringEventID: seriesId,
+		RecurrenceID:     e.RecurrenceID,
+		Transparent:      e.Transparent,
+		UID:              e.UID,
+		Source:           imp.source,
+	}
+	if fresh.Title == "" {
+		fresh.Title = "Busy"
+	}
+	for _, d := range e.ExDates {
+		fresh.AddExDate(d)
+	}
+
+	key := importKey(e.UID, e.RecurrenceID)
+	imp.seen[key] = true
+	row := imp.known[key]
+	if row == nil {
+		fresh.EventCode = newCode("import")
+		if err := imp.tx.CreateEvent(&fresh); err != nil {
+			return storageFailure(err)
+		}
+		imp.known[key] = &fresh
+		imp.result.Created++
+		return nil
+	}
+	if sameImportedEvent(*row, fresh) {
+		imp.result.Unchanged++
+		return nil
+	}
+	row.Title, row.StartTime, row.EndTime = fresh.Title, fresh.StartTime, fresh.EndTime
+	row.RRule, row.ExDates, row.RecurringEventID = fresh.RRule, fresh.ExDates, fresh.RecurringEventID
+	row.Transparent = fresh.Transparent
+	if err := imp.tx.SaveEvent(row); err != nil {
+		return storageFailure(err)
+	}
+	imp.result.Updated++
+	return nil
+}
+
+func sameImportedEvent(a, b model.Event) bool {
+	sameSeries := (a.RecurringEventID == nil) == (b.RecurringEventID == nil) &&
+		(a.RecurringEventID == nil || *a.RecurringEventID == *b.RecurringEventID)
+	return a.Title == b.Title && a.StartTime.Equal(b.StartTime) && a.EndTime.Equal(b.EndTime) &&
+		a.RRule == b.RRule && a.ExDates == b.ExDates && a.Transparent == b.Transparent && sameSeries
+}
+
+// prune deletes the events imported from the source earlier that the feed
+// no longer lists.
+func (imp *importer) prune() error {
+	for key, row := range imp.known {
+		if imp.seen[key] || imp.unread[row.UID] {
+			continue
+		}
+		if err := imp.tx.DeleteEvent(row); err != nil {
+			return storageFailure(err)
+		}
+		delete(imp.known, key)
+		imp.result.Removed++
+	}
+	return nil
+}
+
+func (imp *importer) count(removed bool) {
+	if removed {
+		imp.result.Removed++
+	} else {
+		imp.result.Unchanged++
+	}
+}
+
+func (imp *importer) skip(uid, reason string) {
+	imp.result.Skipped = append(imp.result.Skipped, repository.ImportSkip{UID: uid, Reason: reason})
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/mail"
+	"smart-scheduler/ical"
+	"smart-scheduler/model"
+	"smart-scheduler/notify"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mailer   notify.Mailer
+	mailFrom = "Smart Scheduler <scheduler@localhost>"
+)
+
+// Invitations wait in outbox for a single sender, so a slow mail server
+// never holds up a request and the messages about a meeting go out in the
+// order they were made. pending counts the ones not yet handed over.
+var (
+	outbox      = make(chan outgoing, 256)
+	pending     sync.WaitGroup
+	startSender sync.Once
+)
+
+type outgoing struct {
+	mailer      notify.Mailer
+	meetingCode string
+	msg         notify.Message
+}
+
+// SetMailer sets where meeting invitations are sent and the address they
+// come from. A nil mailer turns invitations off.
+func SetMailer(m notify.Mailer, from string) {
+	mailer = m
+	if from != "" {
+		mailFrom = from
+	}
+}
+
+type notice int
+
+const (
+	noticeInvite notice = iota // a new meeting
+	noticeUpdate               // the meeting moved
+	noticeCancel               // the meeting was cancelled
+)
+
+// notifyAttendees queues the meeting as an iTIP REQUEST or CANCEL to every
+// attendee with an email address, except the organizer. Failures are
+// logged: the meeting is already committed and must not be undone.
+func notifyAttendees(meeting *model.Meeting, kind notice) {
+	if mailer == nil || len(meeting.Attendees) == 0 {
+		return
+	}
+	msg, err := invitation(meeting, kind)
+	if err != nil {
+		log.Printf("Failed to build invitation for meeting %s: %v", meeting.MeetingCode, err)
+		return
+	}
+	if len(msg.To) == 0 {
+		return
+	}
+	startSender.Do(func() { go sendInvitations() })
+	pending.Add(1)
+	select {
+	case outbox <- outgoing{mailer: mailer, meetingCode: meeting.MeetingCode, msg: *msg}:
+	default:
+		pending.Done()
+		log.Printf("Dropped %s for meeting %s: too many invitations waiting", msg.Method, meeting.MeetingCode)
+	}
+}
+
+// sendInvitations hands the queued invitations to their mailer one by one.
+func sendInvitations() {
+	for out := range outbox {
+		if err := out.mailer.Send(out.msg); err != nil {
+			log.Printf("Failed to send %s for meeting %s: %v", out.msg.Method, out.meetingCode, err)
+		}
+		pending.Done()
+	}
+}
+
+// invitation builds the iMIP message for meeting, in the organizer's zone.
+func invitation(meeting *model.Meeting, kind notice) (*notify.Message, error) {
+	ids := []string{meeting.OrganizerID}
+	for _, a := range meeting.Attendees {
+		ids = append(ids, a.UserID)
+	}
+	users, err := loadUsers(ids)
+	if err != nil {
+		return nil, err
+	}
+	organizer := users[meeting.OrganizerID]
+
+	event := ical.Event{
+		UID:         uidFor(meeting.MeetingCode),
+		Sequence:    meeting.Sequence,
+		Stamp:       time.Now(),
+		Start:       meeting.StartTime,
+		End:         meeting.EndTime,
+		Summary:     meeting.Title,
+		Description: meeting.Description,
+		Location:    meeting.Location,
+		RRule:       meeting.RRule,
+		Status:      "CONFIRMED",
+	}
+	method, subject := "REQUEST", "Invitation: "
+	switch kind {
+	case noticeUpdate:
+		subject = "Updated invitation: "
+	case noticeCancel:
+		method, subject = "CANCEL", "Cancelled: "
+		event.Status = "CANCELLED"
+	}
+	// A REQUEST must name an organizer. Without an address of their own the
+	// scheduler's sender stands in, so replies still reach someone.
+	if organizer.Email != "" {
+		event.Organizer = &ical.Attendee{Email: organizer.Email, Name: organizer.Name}
+	} else if from, err := mail.ParseAddress(mailFrom); err == nil {
+		event.Organizer = &ical.Attendee{Email: from.Address, Name: from.Name}
+	}
+
+	msg := &notify.Message{From: mailFrom, Method: method}
+	if organizer.Email != "" {
+		msg.ReplyTo = (&mail.Address{Name: organizer.Name, Address: organizer.Email}).String()
+	}
+	for _, a := range meeting.Attendees {
+		user, ok := users[a.UserID]
+		if !ok || user.Email == "" {
+			log.Printf("Not inviting %s to meeting %s: no email address", a.UserID, meeting.MeetingCode)
+			continue
+		}
+		attendee := ical.Attendee{
+			Email:    user.Email,
+			Name:     user.Name,
+			Role:     "REQ-PARTICIPANT",
+			PartStat: strings.ToUpper(a.Status),
+			RSVP:     kind != noticeCancel,
+		}
+		if a.Optional {
+			attendee.Role = "OPT-PARTICIPANT"
+		}
+		if a.UserID == meeting.OrganizerID {
+			// The organizer chairs the meeting and is not asked to reply
+			attendee.Role, attendee.PartStat, attendee.RSVP = "CHAIR", "ACCEPTED", false
+		} else {
+			msg.To = append(msg.To, user.Email)
+		}
+		event.Attendees = append(event.Attendees, attendee)
+	}
+
+	cal := &ical.Calendar{Method: method, TimeZone: organizer.Location(), Events: []ical.Event{event}}
+	var data bytes.Buffer
+	if err := cal.Encode(&data); err != nil {
+		return nil, err
+	}
+	msg.Calendar = data.Bytes()
+
+	when := meeting.StartTime
+	if loc := organizer.Location(); loc != nil {
+		when = when.In(loc)
+	}
+	msg.Subject = subject + meeting.Title + " @ " + when.Format("Mon 2 Jan 2006 15:04 MST")
+	msg.Text = invitationText(meeting, kind, when)
+	return msg, nil
+}
+
+func invitationText(meeting *model.Meeting, kind notice, when time.Time) string {
+	var b strings.Builder
+	switch kind {
+	case noticeInvite:
+		fmt.Fprintf(&b, "You have been invited to %q.\n\n", meeting.Title)
+	case noticeUpdate:
+		fmt.Fprintf(&b, "%q has moved.\n\n", meeting.Title)
+	case noticeCancel:
+		fmt.Fprintf(&b, "%q has been cancelled.\n\n", meeting.Title)
+	}
+	fmt.Fprintf(&b, "When: %s to %s\n", when.Format("Mon 2 Jan 2006 15:04"), when.Add(meeting.EndTime.Sub(meeting.StartTime)).Format("15:04 MST"))
+	if meeting.RRule != "" {
+		fmt.Fprintf(&b, "Repeats: %s\n", meeting.RRule)
+	}
+	if meeting.Location != "" {
+		fmt.Fprintf(&b, "Where: %s\n", meeting.Location)
+	}
+	if meeting.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", meeting.Description)
+	}
+	return b.String()
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
+	"smart-scheduler/repository"
+	"time"
+)
+
+var ErrMeetingNotFound = apperr.New(apperr.Kind{Code: "meeting_not_found", Title: "Meeting not found", Status: http.StatusNotFound}, "meeting not found")
+
+func GetMeeting(meetingCode string) (*model.Meeting, error) {
+	return findMeeting(store, meetingCode)
+}
+
+func findMeeting(tx repository.Store, meetingCode string) (*model.Meeting, error) {
+	meeting, err := tx.FindMeeting(meetingCode)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrMeetingNotFound
+	}
+	return meeting, storageFailure(err)
+}
+
+// newCode returns a code such as "meeting-20250809103000-9f3c1a2b". The
+// random suffix keeps codes unique when several are created in one second.
+func newCode(prefix string) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return prefix + "-" + time.Now().Format("20060102150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// newMeeting builds the meeting record for a scheduled slot, with every
+// participant invited and awaiting a response.
+func newMeeting(meetingCode string, req repository.ScheduleRequest, title string, slot repository.Slot, rrule string) *model.Meeting {
+	meeting := &model.Meeting{
+		MeetingCode: meetingCode,
+		OrganizerID: organizerOf(req),
+		Title:       title,
+		Description: req.Description,
+		Location:    req.Location,
+		StartTime:   slot.Start,
+		EndTime:     slot.End,
+		RRule:       rrule,
+	}
+	for _, userId := range req.ParticipantIds {
+		meeting.Attendees = append(meeting.Attendees, model.MeetingAttendee{
+			UserID: userId,
+			Status: model.RSVPNeedsAction,
+		})
+	}
+	for _, userId := range req.OptionalIds {
+		meeting.Attendees = append(meeting.Attendees, model.MeetingAttendee{
+			UserID:   userId,
+			Optional: true,
+			Status:   model.RSVPNeedsAction,
+		})
+	}
+	return meeting
+}
+
+func meetingResponse(meeting *model.Meeting) *repository.ScheduledMeetingResponse {
+	return &repository.ScheduledMeetingResponse{
+		MeetingID:      meeting.MeetingCode,
+		Title:          meeting.Title,
+		OrganizerID:    meeting.OrganizerID,
+		Description:    meeting.Description,
+		Location:       meeting.Location,
+		ParticipantIds: meeting.RequiredIDs(),
+		OptionalIds:    meeting.OptionalIDs(),
+		ResourceIds:    meeting.ResourceIDs(),
+		StartTime:      meeting.StartTime.Format(time.RFC3339),
+		EndTime:        meeting.EndTime.Format(time.RFC3339),
+		Status:         repository.StatusConfirmed,
+		Recurrence:     meeting.RRule,
+	}
+}
+
+// bookMeeting stores the meeting together with its attendees and resources
+// once the required attendees and the resources are confirmed to still be
+// free. tx must be a transaction so that the check and the insert commit
+// together.
+func bookMeeting(tx repository.Store, meeting *model.Meeting, check bookingCheck) error {
+	if err := reserve(tx, meeting.RequiredIDs(), meeting.StartTime, meeting.EndTime, meeting.RRule, check); err != nil {
+		return err
+	}
+	if err := reserveResources(tx, meeting.ResourceIDs(), meeting.StartTime, meeting.EndTime, check); err != nil {
+		return err
+	}
+	return storageFailure(tx.CreateMeeting(meeting))
+}
+
+// userMeetingsInRange returns the meetings userId attends that overlap
+// [start, end), as calendar entries with recurring meetings expanded.
+func userMeetingsInRange(tx repository.Store, userId string, start, end time.Time) ([]model.Event, error) {
+	meetings, err := tx.MeetingsAttendedInRange(userId, start, end)
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+
+	var events []model.Event
+	for _, m := range meetings {
+		event := m.EventFor(userId)
+		if !event.IsRecurring() {
+			events = append(events, event)
+			continue
+		}
+		events = append(events, expandSeries(event, nil, start, end)...)
+	}
+	return events, nil
+}
+
+// userMeetings returns every meeting userId attends as unexpanded calendar
+// entries.
+func userMeetings(tx repository.Store, userId string) ([]model.Event, error) {
+	meetings, err := tx.MeetingsAttendedBy(userId)
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	events := make([]model.Event, 0, len(meetings))
+	for _, m := range meetings {
+		events = append(events, m.EventFor(userId))
+	}
+	return events, nil
+}
+
+// legacyMeetingCode matches the codes of meetings booked before meetings
+// were stored as one record, such as "meeting-20250809103000".
+var legacyMeetingCode = regexp.MustCompile(`^meeting-[0-9]{14}$`)
+
+// CancelMeeting removes the meeting and its attendees in one transaction.
+// Meetings booked before meetings were stored as one record are kept as a
+// per-participant event ("<code>-<user>"), and those rows are removed instead.
+// The cancelled meeting is returned so callers can notify attendees.
+func CancelMeeting(meetingCode string) (*model.Meeting, error) {
+	var cancelled *model.Meeting
+	err := store.Transaction(func(tx repository.Store) error {
+		meeting, err := findMeeting(tx, meetingCode)
+		if errors.Is(err, ErrMeetingNotFound) {
+			if !legacyMeetingCode.MatchString(meetingCode) {
+				return ErrMeetingNotFound
+			}
+			deleted, err := tx.DeleteLegacyMeetingEvents(meetingCode)
+			if err != nil {
+				return storageFailure(err)
+			}
+			if deleted == 0 {
+				return ErrMeetingNotFound
+			}
+			cancelled = &model.Meeting{MeetingCode: meetingCode}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.DeleteMeeting(meeting); err != nil {
+			return storageFailure(err)
+		}
+		cancelled = meeting
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Cancelled meeting %s", meetingCode)
+	cancelled.Sequence++
+	notifyAttendees(cancelled, noticeCancel)
+	return cancelled, nil
+}
+
+// RescheduleMeeting reruns the slot search for the meeting's attendees and
+// moves it to the best slot. A recurring meeting keeps the rule the search
+// checked, as it was read in the new range's zone. The meeting's own current
+// slot does not count as busy time. Attendees have to respond again after
+// the move.
+func RescheduleMeeting(meetingCode string, req repository.RescheduleRequest) (*repository.ScheduledMeetingResponse, error) {
+	meeting, err := findMeeting(store, meetingCode)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := req.DurationMinutes
+	if duration <= 0 {
+		duration = int(meeting.EndTime.Sub(meeting.StartTime) / time.Minute)
+	}
+	search := repository.ScheduleRequest{
+		Title:            meeting.Title,
+		OrganizerID:      meeting.OrganizerID,
+		ParticipantIds:   meeting.RequiredIDs(),
+		OptionalIds:      meeting.OptionalIDs(),
+		DurationMinutes:  duration,
+		TimeRange:        req.TimeRange,
+		Recurrence:       meeting.RRule,
+		IgnoreDeclined:   req.IgnoreDeclined,
+		StepMinutes:      req.StepMinutes,
+		AlignToClock:     req.AlignToClock,
+		ResourceIds:      meeting.ResourceIDs(),
+		ExcludeMeetingID: meeting.ID,
+	}
+	slots, rrule, err := searchSlots(&search)
+	if err != nil {
+		return nil, err
+	}
+	chosen := slots[0].Slot
+
+	err = store.Transaction(func(tx repository.Store) error {
+		check := searchCheck(search)
+		check.known = slots[0].conflicts
+		if err := reserve(tx, meeting.RequiredIDs(), chosen.Start, chosen.End, rrule, check); err != nil {
+			return err
+		}
+		if err := reserveResources(tx, meeting.ResourceIDs(), chosen.Start, chosen.End, check); err != nil {
+			return err
+		}
+		meeting.StartTime = chosen.Start
+		meeting.EndTime = chosen.End
+		meeting.RRule = rrule
+		meeting.Sequence++
+		if err := tx.SaveMeeting(meeting); err != nil {
+			return storageFailure(err)
+		}
+		for i := range meeting.Attendees {
+			meeting.Attendees[i].Status = model.RSVPNeedsAction
+		}
+		return storageFailure(tx.ResetRSVPs(meeting.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Rescheduled meeting %s to %v", meetingCode, chosen.Start)
+	notifyAttendees(meeting, noticeUpdate)
+	resp := meetingResponse(meeting)
+	resp.Conflicts = slots[0].conflicts
+	return resp, nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
+	"smart-scheduler/repository"
+	"strings"
+	"time"
+)
+
+var ErrUserNotFound = apperr.New(apperr.Kind{Code: "user_not_found", Title: "User not found", Status: http.StatusNotFound}, "user not found")
+
+func GetUserProfile(userCode string) (*repository.UserProfile, error) {
+	user, err := findUser(store, userCode)
+	if err != nil {
+		return nil, err
+	}
+	return toUserProfile(*user), nil
+}
+
+// UpdateUserProfile replaces the user's time zone, email address and all of
+// their working hours windows with the ones in profile.
+func UpdateUserProfile(userCode string, profile repository.UserProfile) (*repository.UserProfile, error) {
+	var v validator
+	validateTimezone(&v, "timezone", profile.Timezone)
+	validateEmail(&v, "email", profile.Email)
+	windows := parseWorkingWindows(&v, userCode, profile.WorkingHours)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	var user *model.User
+	err := store.Transaction(func(tx repository.Store) error {
+		var err error
+		if user, err = findUser(tx, userCode); err != nil {
+			return err
+		}
+		return storageFailure(tx.UpdateUserProfile(userCode, profile.Timezone, profile.Email, windows))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.Timezone = profile.Timezone
+	user.Email = profile.Email
+	user.WorkingHours = windows
+	return toUserProfile(*user), nil
+}
+
+func toUserProfile(user model.User) *repository.UserProfile {
+	return &repository.UserProfile{
+		UserCode:     user.UserCode,
+		Timezone:     user.Timezone,
+		Email:        user.Email,
+		WorkingHours: toWorkingWindows(user.WorkingHours),
+	}
+}
+
+func toWorkingWindows(hours []model.WorkingHours) []repository.WorkingWindow {
+	windows := []repository.WorkingWindow{}
+	for _, w := range hours {
+		windows = append(windows, repository.WorkingWindow{
+			Weekday: strings.ToLower(w.Weekday.String()),
+			Start:   formatClock(w.StartMinute),
+			End:     formatClock(w.EndMinute),
+		})
+	}
+	return windows
+}
+
+// validateTimezone accepts an IANA zone name, or "" for none.
+func validateTimezone(v *validator, path, timezone string) {
+	if timezone == "" {
+		return
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		v.add(path, apperr.CodeInvalidValue, "is not a known IANA time zone such as Asia/Kolkata")
+	}
+}
+
+// validateEmail accepts a bare address such as "alice@example.com", or "".
+func validateEmail(v *validator, path, email string) {
+	if email == "" {
+		return
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		v.add(path, apperr.CodeInvalidFormat, "must be a bare address such as alice@example.com")
+	}
+}
+
+// parseWorkingWindows converts the windows of a profile, reporting each
+// invalid one as a field error.
+func parseWorkingWindows(v *validator, userCode string, windows []repository.WorkingWindow) []model.WorkingHours {
+	hours := make([]model.WorkingHours, 0, len(windows))
+	for i, w := range windows {
+		wh, err := parseWorkingWindow(w)
+		if err != nil {
+			v.add(fmt.Sprintf("workingHours[%d]", i), apperr.CodeInvalidValue, "%v", err)
+			continue
+		}
+		wh.UserID = userCode
+		hours = append(hours, wh)
+	}
+	return hours
+}
+
+func parseWorkingWindow(w repository.WorkingWindow) (model.WorkingHours, error) {
+	weekday, ok := parseWeekday(w.Weekday)
+	if !ok {
+		return model.WorkingHours{}, fmt.Errorf("invalid weekday %q", w.Weekday)
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return model.WorkingHours{}, err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return model.WorkingHours{}, err
+	}
+	if end <= start {
+		return model.WorkingHours{}, fmt.Errorf("working hours on %s must end after they start", w.Weekday)
+	}
+	return model.WorkingHours{Weekday: weekday, StartMinute: start, EndMinute: end}, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// parseClock converts "HH:MM" into minutes after midnight. "24:00" is
+// accepted so that a window can run until the end of the day.
+func parseClock(value string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(value, "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return h*60 + m, nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
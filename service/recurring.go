@@ -0,0 +1,438 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
+	"smart-scheduler/recurrence"
+	"smart-scheduler/repository"
+	"sort"
+	"time"
+)
+
+var (
+	ErrEventNotFound     = apperr.New(apperr.Kind{Code: "event_not_found", Title: "Event not found", Status: http.StatusNotFound}, "event not found")
+	ErrInvalidOccurrence = apperr.New(apperr.Kind{Code: "invalid_occurrence", Title: "Not an occurrence of the series", Status: http.StatusUnprocessableEntity}, "occurrence is not part of the recurring series")
+)
+
+// userEventsInRange returns the user's events and the meetings they attend
+// overlapping [start, end), with recurring series expanded into their
+// individual occurrences.
+func userEventsInRange(tx repository.Store, userId string, start, end time.Time) ([]model.Event, error) {
+	events, err := tx.OneOffEventsInRange(userId, start, end)
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+
+	masters, err := tx.SeriesInRange(userId, start, end)
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+
+	if len(masters) > 0 {
+		masterIds := make([]uint, 0, len(masters))
+		for _, m := range masters {
+			masterIds = append(masterIds, m.ID)
+		}
+		overrides, err := tx.Overrides(masterIds)
+		if err != nil {
+			return nil, storageFailure(err)
+		}
+		byMaster := make(map[uint][]model.Event)
+		for _, o := range overrides {
+			byMaster[*o.RecurringEventID] = append(byMaster[*o.RecurringEventID], o)
+		}
+		for _, m := range masters {
+			events = append(events, expandSeries(m, byMaster[m.ID], start, end)...)
+		}
+	}
+
+	meetings, err := userMeetingsInRange(tx, userId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, meetings...)
+
+	sortByStart(events)
+	return events, nil
+}
+
+func sortByStart(events []model.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StartTime.Before(events[j].StartTime)
+	})
+}
+
+// expandSeries turns a series master into the occurrences overlapping
+// [from, to), skipping EXDATEs and substituting overridden occurrences.
+func expandSeries(master model.Event, overrides []model.Event, from, to time.Time) []model.Event {
+	rule, err := recurrence.Parse(master.RRule, master.StartTime.Location())
+	if err != nil {
+		log.Printf("Skipping series %s with invalid rule %q: %v", master.EventCode, master.RRule, err)
+		return nil
+	}
+
+	var occurrences []model.Event
+	overridden := make(map[int64]bool, len(overrides))
+	for _, o := range overrides {
+		if o.RecurrenceID != nil {
+			overridden[o.RecurrenceID.UnixNano()] = true
+		}
+		if o.StartTime.Before(to) && o.EndTime.After(from) {
+			occurrences = append(occurrences, o)
+		}
+	}
+
+	duration := master.EndTime.Sub(master.StartTime)
+	for _, start := range rule.Between(master.StartTime, duration, from, to) {
+		if master.IsExcluded(start) || overridden[start.UnixNano()] {
+			continue
+		}
+		occurrences = append(occurrences, occurrenceOf(master, start))
+	}
+	return occurrences
+}
+
+// occurrenceOf builds the virtual event for one occurrence of a series. It
+// keeps the series ID so clients can edit it with an occurrence reference.
+func occurrenceOf(master model.Event, start time.Time) model.Event {
+	recurrenceId := start
+	occ := master
+	occ.StartTime = start
+	occ.EndTime = start.Add(master.EndTime.Sub(master.StartTime))
+	occ.RRule = ""
+	occ.ExDates = ""
+	occ.SeriesEnd = nil
+	occ.RecurrenceID = &recurrenceId
+	if master.ID != 0 {
+		// Meeting occurrences have no stored series row to point at
+		seriesId := master.ID
+		occ.RecurringEventID = &seriesId
+	}
+	return occ
+}
+
+// UpdateEvent edits a plain event, or one, the following, or all
+// occurrences of a recurring series depending on req.Scope.
+func UpdateEvent(eventId uint, req repository.EventUpdateRequest) (*model.Event, error) {
+	var updated *model.Event
+	err := store.Transaction(func(tx repository.Store) error {
+		master, err := findEvent(tx, eventId)
+		if err != nil {
+			return err
+		}
+		if master.Source != "" {
+			return ErrImportedEvent
+		}
+
+		if !master.IsRecurring() {
+			start, end, err := retime(master.StartTime, master.EndTime, req)
+			if err != nil {
+				return err
+			}
+			master.StartTime, master.EndTime = start, end
+			if req.Title != nil {
+				master.Title = *req.Title
+			}
+			updated = master
+			return storageFailure(tx.SaveEvent(master))
+		}
+
+		scope, occ, err := resolveScope(master, req.Scope, req.Occurrence)
+		if err != nil {
+			return err
+		}
+		switch scope {
+		case repository.ScopeThis:
+			updated, err = updateOccurrence(tx, master, occ, req)
+		case repository.ScopeFollowing:
+			updated, err = updateFollowing(tx, master, occ, req)
+		default:
+			updated, err = updateSeries(tx, master, occ, req)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteEvent removes a plain event, or one, the following, or all
+// occurrences of a recurring series.
+func DeleteEvent(eventId uint, scope, occurrence string) error {
+	return store.Transaction(func(tx repository.Store) error {
+		master, err := findEvent(tx, eventId)
+		if err != nil {
+			return err
+		}
+		if master.Source != "" {
+			return ErrImportedEvent
+		}
+		return deleteEvent(tx, master, scope, occurrence)
+	})
+}
+
+// deleteEvent removes master, or the occurrences of it that scope selects,
+// inside tx.
+func deleteEvent(tx repository.Store, master *model.Event, scope, occurrence string) error {
+	if !master.IsRecurring() {
+		// Deleting an override removes that occurrence from its series
+		if master.RecurringEventID != nil && master.RecurrenceID != nil {
+			if series, err := tx.FindEvent(*master.RecurringEventID); err == nil {
+				series.AddExDate(*master.RecurrenceID)
+				if err := tx.SaveEvent(series); err != nil {
+					return storageFailure(err)
+				}
+			}
+		}
+		return storageFailure(tx.DeleteEvent(master))
+	}
+
+	scope, occ, err := resolveScope(master, scope, occurrence)
+	if err != nil {
+		return err
+	}
+	switch scope {
+	case repository.ScopeThis:
+		master.AddExDate(occ)
+		if override, err := tx.FindOverride(master.ID, occ); err == nil {
+			if err := tx.DeleteEvent(override); err != nil {
+				return storageFailure(err)
+			}
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return storageFailure(err)
+		}
+		return storageFailure(tx.SaveEvent(master))
+	case repository.ScopeFollowing:
+		if err := truncateSeries(tx, master, occ); err != nil {
+			return err
+		}
+		return storageFailure(tx.DeleteOverrides(master.ID, occ))
+	default:
+		if err := tx.DeleteOverrides(master.ID, time.Time{}); err != nil {
+			return storageFailure(err)
+		}
+		return storageFailure(tx.DeleteEvent(master))
+	}
+}
+
+func findEvent(tx repository.Store, eventId uint) (*model.Event, error) {
+	event, err := tx.FindEvent(eventId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrEventNotFound
+	}
+	return event, storageFailure(err)
+}
+
+// resolveScope validates the scope and, for "this" and "following", the
+// occurrence it refers to. Editing "following" from the first occurrence is
+// the same as editing the whole series.
+func resolveScope(master *model.Event, scope, occurrence string) (string, time.Time, error) {
+	if scope == "" {
+		scope = repository.ScopeAll
+	}
+	if scope != repository.ScopeThis && scope != repository.ScopeFollowing && scope != repository.ScopeAll {
+		return "", time.Time{}, apperr.Invalid("scope", apperr.CodeInvalidValue, "must be this, following or all")
+	}
+	if occurrence == "" {
+		if scope != repository.ScopeAll {
+			return "", time.Time{}, apperr.Invalid("occurrence", apperr.CodeRequired, fmt.Sprintf("is required for scope %q", scope))
+		}
+		return scope, master.StartTime, nil
+	}
+
+	occ, err := time.Parse(time.RFC3339, occurrence)
+	if err != nil {
+		return "", time.Time{}, apperr.Invalid("occurrence", apperr.CodeInvalidFormat, "must be an RFC 3339 time such as 2025-08-09T09:00:00+05:30")
+	}
+	rule, err := recurrence.Parse(master.RRule, master.StartTime.Location())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	// Overrides and EXDATEs are kept in the series' zone, whatever offset
+	// the client wrote the occurrence in
+	occ = occ.In(master.StartTime.Location())
+	if !rule.Includes(master.StartTime, occ) || master.IsExcluded(occ) {
+		return "", time.Time{}, ErrInvalidOccurrence
+	}
+	if scope == repository.ScopeFollowing && occ.Equal(master.StartTime) {
+		scope = repository.ScopeAll
+	}
+	return scope, occ, nil
+}
+
+// retime applies the requested start/end to an occurrence currently at
+// [start, end). Moving only the start keeps the original duration.
+func retime(start, end time.Time, req repository.EventUpdateRequest) (time.Time, time.Time, error) {
+	var v validator
+	newStart, newEnd := start, end
+	if req.StartTime != nil {
+		if t, ok := v.time("startTime", *req.StartTime); ok {
+			newStart = t
+			newEnd = t.Add(end.Sub(start))
+		}
+	}
+	if req.EndTime != nil {
+		if t, ok := v.time("endTime", *req.EndTime); ok {
+			newEnd = t
+		}
+	}
+	if len(v.fields) == 0 && !newEnd.After(newStart) {
+		v.add("endTime", apperr.CodeOutOfRange, "must be after startTime")
+	}
+	if err := v.err(); err != nil {
+		return start, end, err
+	}
+	return newStart, newEnd, nil
+}
+
+func updateOccurrence(tx repository.Store, master *model.Event, occ time.Time, req repository.EventUpdateRequest) (*model.Event, error) {
+	var override model.Event
+	found, err := tx.FindOverride(master.ID, occ)
+	if errors.Is(err, repository.ErrNotFound) {
+		override = occurrenceOf(*master, occ)
+		override.ID = 0
+		override.EventCode = master.EventCode + "-" + occ.UTC().Format("20060102T150405Z")
+	} else if err != nil {
+		return nil, storageFailure(err)
+	} else {
+		override = *found
+	}
+
+	start, end, err := retime(override.StartTime, override.EndTime, req)
+	if err != nil {
+		return nil, err
+	}
+	override.StartTime, override.EndTime = start, end
+	if req.Title != nil {
+		override.Title = *req.Title
+	}
+	if err := tx.SaveEvent(&override); err != nil {
+		return nil, storageFailure(err)
+	}
+	return &override, nil
+}
+
+func updateSeries(tx repository.Store, master *model.Event, occ time.Time, req repository.EventUpdateRequest) (*model.Event, error) {
+	duration := master.EndTime.Sub(master.StartTime)
+	start, end, err := retime(occ, occ.Add(duration), req)
+	if err != nil {
+		return nil, err
+	}
+
+	if delta := start.Sub(occ); delta != 0 || end.Sub(start) != duration {
+		// Moving the whole series invalidates per-occurrence exceptions
+		master.StartTime = master.StartTime.Add(delta)
+		master.EndTime = master.StartTime.Add(end.Sub(start))
+		master.ExDates = ""
+		if err := tx.DeleteOverrides(master.ID, time.Time{}); err != nil {
+			return nil, storageFailure(err)
+		}
+	}
+	if req.Title != nil {
+		master.Title = *req.Title
+	}
+	if err := tx.SaveEvent(master); err != nil {
+		return nil, storageFailure(err)
+	}
+	return master, nil
+}
+
+// updateFollowing splits the series at occ: the original master ends just
+// before it and a new series carrying the edits starts from it.
+func updateFollowing(tx repository.Store, master *model.Event, occ time.Time, req repository.EventUpdateRequest) (*model.Event, error) {
+	rule, err := recurrence.Parse(master.RRule, master.StartTime.Location())
+	if err != nil {
+		return nil, err
+	}
+	duration := master.EndTime.Sub(master.StartTime)
+	start, end, err := retime(occ, occ.Add(duration), req)
+	if err != nil {
+		return nil, err
+	}
+	delta := start.Sub(occ)
+
+	tailRule := *rule
+	if rule.Count > 0 {
+		tailRule.Count = rule.Count - rule.CountBefore(master.StartTime, occ)
+	}
+	if !rule.Until.IsZero() {
+		tailRule.Until = rule.Until.Add(delta)
+	}
+	// A move onto another weekday takes the rule's weekdays along, so the
+	// new start is still an occurrence of the tail
+	loc := master.StartTime.Location()
+	if shift := (start.In(loc).Weekday() - occ.In(loc).Weekday() + 7) % 7; shift != 0 && len(rule.ByDay) > 0 {
+		tailRule.ByDay = make([]time.Weekday, len(rule.ByDay))
+		for i, day := range rule.ByDay {
+			tailRule.ByDay[i] = (day + shift) % 7
+		}
+	}
+	tail := model.Event{
+		// Overrides are coded "<series>-<occurrence>", so the tail gets a
+		// different suffix to stay clear of an override at occ
+		EventCode:   master.EventCode + "-from-" + occ.UTC().Format("20060102T150405Z"),
+		UserID:      master.UserID,
+		Title:       master.Title,
+		StartTime:   start,
+		EndTime:     end,
+		RRule:       tailRule.String(),
+		Transparent: master.Transparent,
+	}
+	if req.Title != nil {
+		tail.Title = *req.Title
+	}
+	for _, ex := range master.ExceptionDates() {
+		if !ex.Before(occ) {
+			tail.AddExDate(ex.Add(delta))
+		}
+	}
+
+	if err := truncateSeries(tx, master, occ); err != nil {
+		return nil, err
+	}
+	if err := tx.CreateEvent(&tail); err != nil {
+		return nil, storageFailure(err)
+	}
+
+	// Later overrides follow the new series when it has not moved
+	if delta == 0 && duration == end.Sub(start) {
+		err = tx.MoveOverrides(master.ID, tail.ID, occ)
+	} else {
+		err = tx.DeleteOverrides(master.ID, occ)
+	}
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	return &tail, nil
+}
+
+// truncateSeries ends the series just before the occurrence at occ.
+func truncateSeries(tx repository.Store, master *model.Event, occ time.Time) error {
+	rule, err := recurrence.Parse(master.RRule, master.StartTime.Location())
+	if err != nil {
+		return err
+	}
+	if rule.Count > 0 {
+		rule.Count = rule.CountBefore(master.StartTime, occ)
+	} else {
+		rule.Until = occ.Add(-time.Second)
+	}
+	master.RRule = rule.String()
+
+	var kept []time.Time
+	for _, ex := range master.ExceptionDates() {
+		if ex.Before(occ) {
+			kept = append(kept, ex)
+		}
+	}
+	master.ExDates = ""
+	for _, ex := range kept {
+		master.AddExDate(ex)
+	}
+	return storageFailure(tx.SaveEvent(master))
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"smart-scheduler/model"
+	"smart-scheduler/recurrence"
+	"smart-scheduler/repository"
+	"smart-scheduler/scoring"
+	"sort"
+	"time"
+)
+
+// maxMeetingOccurrences caps how many occurrences of an open-ended recurring
+// meeting are checked and booked.
+const maxMeetingOccurrences = 52
+
+// parseMeetingRecurrence reads the request's recurrence pattern in loc, the
+// zone of the request's time range, bounding open-ended rules to
+// maxMeetingOccurrences.
+func parseMeetingRecurrence(value string, loc *time.Location) (*recurrence.Rule, error) {
+	rule, err := recurrence.Parse(value, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence: %w", err)
+	}
+	if !rule.Bounded() {
+		rule.Count = maxMeetingOccurrences
+	}
+	return rule, nil
+}
+
+// rankRecurringSlots evaluates each candidate first occurrence inside the
+// request's time range against every later occurrence of the series. Slots
+// are ordered by number of colliding occurrences, then by total score. An
+// occurrence collides when a required attendee is busy or quorum is not met.
+// Every grid start is a candidate, even one whose first occurrence collides,
+// so instead of freeStarts each occurrence is checked against the merged
+// busy time with a binary search.
+func rankRecurringSlots(req repository.ScheduleRequest, rule *recurrence.Rule, scorer scoring.Scorer) ([]scoredSlot, error) {
+	startTime, _ := time.Parse(time.RFC3339, req.TimeRange.Start)
+	endTime, _ := time.Parse(time.RFC3339, req.TimeRange.End)
+	slotDuration := time.Duration(req.DurationMinutes) * time.Minute
+
+	attendeeIds := append(append([]string{}, req.ParticipantIds...), req.OptionalIds...)
+	users, err := loadUsers(append([]string{organizerOf(req)}, attendeeIds...))
+	if err != nil {
+		return nil, err
+	}
+
+	// Busy time must cover the series of the latest possible candidate
+	horizon := endTime
+	if last, ok := rule.Last(endTime.Add(-slotDuration)); ok {
+		horizon = last.Add(slotDuration)
+	}
+	loaded := repository.Slot{Start: startTime.Add(-scoringMargin), End: horizon.Add(scoringMargin)}
+	eventMap, err := loadBusy(attendeeIds, loaded.Start, loaded.End, searchCheck(req))
+	if err != nil {
+		return nil, err
+	}
+	index := indexBusy(eventMap, loaded)
+
+	var slots []scoredSlot
+	for _, t := range candidateStarts(req, users, startTime, endTime, slotDuration) {
+		// The first occurrence is the booked start, so it must match the
+		// rule itself, such as falling on one of its BYDAY weekdays
+		if !rule.Includes(t, t) {
+			continue
+		}
+		candidate := scoredSlot{
+			Slot:              repository.Slot{Start: t, End: t.Add(slotDuration)},
+			participantScores: make(map[string]int, len(attendeeIds)),
+		}
+
+		rule.Each(t, func(occ time.Time) bool {
+			slot := repository.Slot{Start: occ, End: occ.Add(slotDuration)}
+			busy := unavailableParticipants(slot, req.ParticipantIds, index, users)
+			missing := unavailableParticipants(slot, req.OptionalIds, index, users)
+			noQuorum := req.MinQuorum > 0 && len(attendeeIds)-len(busy)-len(missing) < req.MinQuorum
+			if len(busy) > 0 || noQuorum {
+				candidate.conflicts = append(candidate.conflicts, repository.OccurrenceConflict{
+					StartTime:      slot.Start.Format(time.RFC3339),
+					EndTime:        slot.End.Format(time.RFC3339),
+					ParticipantIds: busy,
+				})
+			}
+			for _, userId := range req.ParticipantIds {
+				userScore := scorer.Score(slot, eventMap[userId], users[userId].Location())
+				candidate.participantScores[userId] += userScore
+				candidate.score += userScore
+			}
+			for _, userId := range req.OptionalIds {
+				userScore := missingOptionalPenalty
+				if !contains(missing, userId) {
+					userScore = scorer.Score(slot, eventMap[userId], users[userId].Location())
+				} else if !contains(candidate.unavailable, userId) {
+					candidate.unavailable = append(candidate.unavailable, userId)
+				}
+				candidate.participantScores[userId] += userScore
+				candidate.score += userScore
+			}
+			return true
+		})
+
+		log.Printf("Recurring slot %v has %d colliding occurrences, score %d", t, len(candidate.conflicts), candidate.score)
+		slots = append(slots, candidate)
+	}
+
+	if len(slots) == 0 {
+		return nil, fmt.Errorf("%w for all participants", ErrNoSlotFound)
+	}
+
+	// Stable so that ties keep the earliest slot first
+	sort.SliceStable(slots, func(i, j int) bool {
+		if len(slots[i].conflicts) != len(slots[j].conflicts) {
+			return len(slots[i].conflicts) < len(slots[j].conflicts)
+		}
+		return slots[i].score < slots[j].score
+	})
+
+	return slots, nil
+}
+
+// unavailableParticipants lists who cannot attend slot, either because of
+// an overlapping busy interval or because it is outside their working hours.
+func unavailableParticipants(slot repository.Slot, userIds []string, index busyIndex, users map[string]model.User) []string {
+	var busy []string
+	for _, userId := range userIds {
+		if !users[userId].Available(slot.Start, slot.End) || index.busy(userId, slot) {
+			busy = append(busy, userId)
+		}
+	}
+	return busy
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
+	"smart-scheduler/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrResourceNotFound = apperr.New(apperr.Kind{Code: "resource_not_found", Title: "Resource not found", Status: http.StatusNotFound}, "resource not found")
+	ErrResourceExists   = apperr.New(apperr.Kind{Code: "resource_exists", Title: "Resource already exists", Status: http.StatusConflict}, "resource already exists")
+)
+
+// CreateResource adds a room or piece of equipment. The code must be unused.
+func CreateResource(req repository.ResourceDetails) (*repository.ResourceDetails, error) {
+	var v validator
+	v.code("resourceCode", req.ResourceCode)
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		v.add("name", apperr.CodeRequired, "is required")
+	}
+	if req.Capacity < 0 {
+		v.add("capacity", apperr.CodeOutOfRange, "cannot be negative")
+	}
+	var features []string
+	for i, f := range req.Features {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || strings.Contains(f, ",") {
+			v.add(fmt.Sprintf("features[%d]", i), apperr.CodeInvalidValue, "must be a non-blank name without commas")
+			continue
+		}
+		if !contains(features, f) {
+			features = append(features, f)
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	resource := &model.Resource{
+		ResourceCode: req.ResourceCode,
+		Name:         req.Name,
+		Type:         resourceType(req.Type),
+		Capacity:     req.Capacity,
+		Location:     strings.TrimSpace(req.Location),
+		Features:     strings.Join(features, ","),
+	}
+	err := store.Transaction(func(tx repository.Store) error {
+		_, err := tx.FindResource(resource.ResourceCode)
+		if err == nil {
+			return fmt.Errorf("%w: %s", ErrResourceExists, resource.ResourceCode)
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return storageFailure(err)
+		}
+		// A concurrent request may have taken the code since the lookup
+		err = tx.CreateResource(resource)
+		if errors.Is(err, repository.ErrDuplicate) {
+			return fmt.Errorf("%w: %s", ErrResourceExists, resource.ResourceCode)
+		}
+		return storageFailure(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Created %s %s", resource.Type, resource.ResourceCode)
+	return toResourceDetails(*resource), nil
+}
+
+func GetResource(resourceCode string) (*repository.ResourceDetails, error) {
+	resource, err := findResource(store, resourceCode)
+	if err != nil {
+		return nil, err
+	}
+	return toResourceDetails(*resource), nil
+}
+
+// ListResources returns every resource, or only those of one type.
+func ListResources(resourceType string) ([]repository.ResourceDetails, error) {
+	resources, err := store.ListResources()
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	list := []repository.ResourceDetails{}
+	for _, r := range resources {
+		if resourceType == "" || strings.EqualFold(r.Type, resourceType) {
+			list = append(list, *toResourceDetails(r))
+		}
+	}
+	return list, nil
+}
+
+// DeleteResource removes the resource. Meetings that booked it keep their
+// time and attendees.
+func DeleteResource(resourceCode string) error {
+	err := store.DeleteResource(resourceCode)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrResourceNotFound, resourceCode)
+	}
+	if err != nil {
+		return storageFailure(err)
+	}
+	log.Printf("Deleted resource %s", resourceCode)
+	return nil
+}
+
+// GetResourceCalendar returns the meetings that booked the resource in
+// [start, end).
+func GetResourceCalendar(resourceCode, start, end string) ([]model.Event, error) {
+	var v validator
+	startTime, startOK := v.time("start", start)
+	endTime, endOK := v.time("end", end)
+	if startOK && endOK && !startTime.Before(endTime) {
+		v.add("end", apperr.CodeOutOfRange, "must be after start")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if _, err := findResource(store, resourceCode); err != nil {
+		return nil, err
+	}
+
+	meetings, err := store.MeetingsBookingResourceInRange(resourceCode, startTime, endTime)
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	events := []model.Event{}
+	for _, m := range meetings {
+		event := m.EventFor("")
+		if !event.IsRecurring() {
+			events = append(events, event)
+			continue
+		}
+		events = append(events, expandSeries(event, nil, startTime, endTime)...)
+	}
+	sortByStart(events)
+	return events, nil
+}
+
+func findResource(tx repository.Store, resourceCode string) (*model.Resource, error) {
+	resource, err := tx.FindResource(resourceCode)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, resourceCode)
+	}
+	return resource, storageFailure(err)
+}
+
+func resourceType(value string) string {
+	if value = strings.ToLower(strings.TrimSpace(value)); value == "" {
+		return model.ResourceRoom
+	}
+	return value
+}
+
+func toResourceDetails(r model.Resource) *repository.ResourceDetails {
+	features := r.FeatureList()
+	if features == nil {
+		features = []string{}
+	}
+	return &repository.ResourceDetails{
+		ResourceCode: r.ResourceCode,
+		Name:         r.Name,
+		Type:         r.Type,
+		Capacity:     r.Capacity,
+		Location:     r.Location,
+		Features:     features,
+	}
+}
+
+// resourcePlan is what a schedule request needs besides its attendees:
+// resources that must all be free and candidates of which one must be.
+type resourcePlan struct {
+	fixed      []string
+	candidates []model.Resource // smallest first
+	busy       map[string][]repository.Slot
+}
+
+// planResources loads the resources req asks for and their busy time in
+// [start, end). Requests without resources get an empty plan.
+func planResources(req repository.ScheduleRequest, start, end time.Time) (*resourcePlan, error) {
+	plan := &resourcePlan{busy: make(map[string][]repository.Slot)}
+	for _, code := range req.ResourceIds {
+		if _, err := findResource(store, code); err != nil {
+			return nil, err
+		}
+		if !contains(plan.fixed, code) {
+			plan.fixed = append(plan.fixed, code)
+		}
+	}
+
+	if need := req.Resource; need != nil {
+		resources, err := store.ListResources()
+		if err != nil {
+			return nil, storageFailure(err)
+		}
+		for _, r := range resources {
+			if strings.EqualFold(r.Type, resourceType(need.Type)) && r.Capacity >= need.MinCapacity && r.HasFeatures(need.Features) &&
+				(need.Location == "" || strings.EqualFold(r.Location, need.Location)) && !contains(plan.fixed, r.ResourceCode) {
+				plan.candidates = append(plan.candidates, r)
+			}
+		}
+		if len(plan.candidates) == 0 {
+			return nil, fmt.Errorf("%w: no %s has capacity %d and features %v", ErrNoSlotFound, resourceType(need.Type), need.MinCapacity, need.Features)
+		}
+		// Leave the bigger rooms for bigger meetings
+		sort.SliceStable(plan.candidates, func(i, j int) bool {
+			return plan.candidates[i].Capacity < plan.candidates[j].Capacity
+		})
+	}
+
+	codes := append([]string{}, plan.fixed...)
+	for _, r := range plan.candidates {
+		codes = append(codes, r.ResourceCode)
+	}
+	for _, code := range codes {
+		busy, err := resourceBusy(store, code, start, end, req.ExcludeMeetingID)
+		if err != nil {
+			return nil, err
+		}
+		plan.busy[code] = busy
+	}
+	return plan, nil
+}
+
+// pick returns the resources to book for slot: every fixed resource and the
+// first free candidate. ok is false when a fixed resource is busy or no
+// candidate is free.
+func (p *resourcePlan) pick(slot repository.Slot) (codes []string, ok bool) {
+	for _, code := range p.fixed {
+		if !p.isFree(code, slot) {
+			return nil, false
+		}
+	}
+	codes = append(codes, p.fixed...)
+	if len(p.candidates) == 0 {
+		return codes, true
+	}
+	for _, r := range p.candidates {
+		if p.isFree(r.ResourceCode, slot) {
+			return append(codes, r.ResourceCode), true
+		}
+	}
+	return nil, false
+}
+
+func (p *resourcePlan) isFree(code string, slot repository.Slot) bool {
+	for _, b := range p.busy[code] {
+		if b.Overlaps(slot) {
+			return false
+		}
+	}
+	return true
+}
+
+// resourceBusy returns the time in [start, end) the resource is booked by
+// meetings other than excludeMeetingID.
+func resourceBusy(tx repository.Store, resourceCode string, start, end time.Time, excludeMeetingID uint) ([]repository.Slot, error) {
+	meetings, err := tx.MeetingsBookingResourceInRange(resourceCode, start, end)
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	var busy []repository.Slot
+	for _, m := range meetings {
+		if excludeMeetingID != 0 && m.ID == excludeMeetingID {
+			continue
+		}
+		event := m.EventFor("")
+		occurrences := []model.Event{event}
+		if event.IsRecurring() {
+			occurrences = expandSeries(event, nil, start, end)
+		}
+		for _, occ := range occurrences {
+			busy = append(busy, repository.Slot{Start: occ.StartTime, End: occ.EndTime})
+		}
+	}
+	return busy, nil
+}
+
+// reserveResources locks the resources and re-checks that each is still
+// free for [start, end), like reserve does for participants.
+func reserveResources(tx repository.Store, resourceIds []string, start, end time.Time, check bookingCheck) error {
+	if len(resourceIds) == 0 {
+		return nil
+	}
+	if err := tx.LockResources(resourceIds); err != nil {
+		return storageFailure(err)
+	}
+	slot := repository.Slot{Start: start, End: end}
+	for _, code := range resourceIds {
+		busy, err := resourceBusy(tx, code, start, end, check.excludeMeetingID)
+		if err != nil {
+			return err
+		}
+		for _, b := range busy {
+			if b.Overlaps(slot) {
+				return &ConflictError{ResourceID: code, Start: start, End: end}
+			}
+		}
+	}
+	return nil
+}
+
+// resourceLocation describes where a meeting in the booked resources takes
+// place, for meetings that do not give a location.
+func resourceLocation(codes []string) string {
+	var rooms []string
+	for _, code := range codes {
+		r, err := store.FindResource(code)
+		if err != nil || r.Type != model.ResourceRoom {
+			continue
+		}
+		name := r.Name
+		if r.Location != "" {
+			name += " (" + r.Location + ")"
+		}
+		rooms = append(rooms, name)
+	}
+	return strings.Join(rooms, ", ")
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"smart-scheduler/apperr"
+	"smart-scheduler/ical"
+	"smart-scheduler/model"
+	"smart-scheduler/repository"
+	"strings"
+)
+
+var (
+	ErrNotAttendee   = apperr.New(apperr.Kind{Code: "not_attendee", Title: "Not an attendee", Status: http.StatusNotFound}, "user is not an attendee of the meeting")
+	ErrInvalidReply  = apperr.New(apperr.Kind{Code: "invalid_reply", Title: "Invalid iTIP reply", Status: http.StatusBadRequest}, "invalid iTIP reply")
+	ErrOutdatedReply = apperr.New(apperr.Kind{Code: "outdated_reply", Title: "Reply for an earlier version", Status: http.StatusConflict}, "reply is for an earlier version of the meeting")
+)
+
+// RespondToMeeting records userId's RSVP to the meeting and returns the
+// meeting with its updated attendee list.
+func RespondToMeeting(meetingCode string, req repository.RSVPRequest) (*model.Meeting, error) {
+	status := strings.ToLower(req.Status)
+	if !model.IsRSVPStatus(status) {
+		return nil, apperr.Invalid("status", apperr.CodeInvalidValue, "must be accepted, declined, tentative or needs-action")
+	}
+
+	var meeting *model.Meeting
+	err := store.Transaction(func(tx repository.Store) error {
+		var err error
+		if meeting, err = findMeeting(tx, meetingCode); err != nil {
+			return err
+		}
+		return setRSVP(tx, meeting, req.UserID, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("%s responded %s to meeting %s", req.UserID, status, meetingCode)
+	return meeting, nil
+}
+
+func setRSVP(tx repository.Store, meeting *model.Meeting, userId, status string) error {
+	attendee := meeting.Attendee(userId)
+	if attendee == nil {
+		return fmt.Errorf("%w: %s", ErrNotAttendee, userId)
+	}
+	if err := tx.SetRSVP(meeting.ID, userId, status); err != nil {
+		return storageFailure(err)
+	}
+	attendee.Status = status
+	return nil
+}
+
+// ProcessReply records the responses in an iTIP REPLY (RFC 5546), the
+// calendar object a mail client sends back when an attendee accepts,
+// declines or tentatively accepts an invitation. Attendees are matched by
+// email address and meetings by the UID they were invited with. Replies to
+// a meeting that has since been moved are rejected, since the attendee has
+// not seen the new time.
+func ProcessReply(r io.Reader) ([]repository.RSVPUpdate, error) {
+	cal, invalid, err := ical.Decode(r, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReply, err)
+	}
+	if cal.Method != "REPLY" {
+		return nil, fmt.Errorf("%w: expected METHOD:REPLY, got %q", ErrInvalidReply, cal.Method)
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("%w: event %s: %v", ErrInvalidReply, invalid[0].UID, invalid[0].Err)
+	}
+
+	var updates []repository.RSVPUpdate
+	err = store.Transaction(func(tx repository.Store) error {
+		for _, event := range cal.Events {
+			meetingCode, ok := strings.CutSuffix(event.UID, "@"+uidDomain)
+			if !ok {
+				return fmt.Errorf("%w: %s is not a meeting booked here", ErrMeetingNotFound, event.UID)
+			}
+			meeting, err := findMeeting(tx, meetingCode)
+			if err != nil {
+				return err
+			}
+			if event.Sequence < meeting.Sequence {
+				return fmt.Errorf("%w: got sequence %d, the meeting is at %d", ErrOutdatedReply, event.Sequence, meeting.Sequence)
+			}
+			for _, a := range event.Attendees {
+				status, ok := replyStatuses[a.PartStat]
+				if !ok {
+					return fmt.Errorf("%w: unsupported PARTSTAT %q", ErrInvalidReply, a.PartStat)
+				}
+				user, err := tx.FindUserByEmail(a.Email)
+				if errors.Is(err, repository.ErrNotFound) {
+					return fmt.Errorf("%w: %s", ErrNotAttendee, a.Email)
+				}
+				if err != nil {
+					return storageFailure(err)
+				}
+				if err := setRSVP(tx, meeting, user.UserCode, status); err != nil {
+					return err
+				}
+				updates = append(updates, repository.RSVPUpdate{MeetingID: meetingCode, UserID: user.UserCode, Status: status})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("%w: no ATTENDEE responses found", ErrInvalidReply)
+	}
+
+	for _, u := range updates {
+		log.Printf("%s replied %s to meeting %s", u.UserID, u.Status, u.MeetingID)
+	}
+	return updates, nil
+}
+
+// replyStatuses maps the PARTSTAT values an attendee can reply with to
+// RSVP states.
+var replyStatuses = map[string]string{
+	"ACCEPTED":     model.RSVPAccepted,
+	"DECLINED":     model.RSVPDeclined,
+	"TENTATIVE":    model.RSVPTentative,
+	"NEEDS-ACTION": model.RSVPNeedsAction,
+}
@@ -1,156 +1,372 @@
 package service
 
 import (
-	"errors"
+	"fmt"
 	"log"
-	"smart-scheduler/db"
-	database "smart-scheduler/db"
+	"net/http"
+	"smart-scheduler/apperr"
 	"smart-scheduler/model"
 	"smart-scheduler/repository"
+	"smart-scheduler/scoring"
 	"sort"
 	"time"
-
-	"gorm.io/gorm"
 )
 
+// ErrNoSlotFound is returned when the request is valid but no time in its
+// range suits every required participant and resource.
+var ErrNoSlotFound = apperr.New(apperr.Kind{Code: "no_slot_found", Title: "No available time slot", Status: http.StatusConflict}, "no available time slot found")
+
+type scoredSlot struct {
+	repository.Slot
+	score             int
+	participantScores map[string]int
+	conflicts         []repository.OccurrenceConflict // recurring meetings only
+	unavailable       []string                        // optional attendees who cannot make it
+	resources         []string                        // rooms and equipment to book
+}
+
 func ScheduleEvent(req repository.ScheduleRequest) (*repository.ScheduledMeetingResponse, error) {
+	slots, rrule, err := searchSlots(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	chosen := slots[0].Slot
+
+	// Use provided title or default to "New Meeting"
+	meetingTitle := req.Title
+	if meetingTitle == "" {
+		meetingTitle = "New Meeting"
+	}
+
+	if req.HoldMinutes > 0 {
+		return placeHolds(req, meetingTitle, slots[0])
+	}
+
+	meetingCode := newCode("meeting")
+	meeting := newMeeting(meetingCode, req, meetingTitle, chosen, rrule)
+	for _, code := range slots[0].resources {
+		meeting.Resources = append(meeting.Resources, model.MeetingResource{ResourceID: code})
+	}
+	if meeting.Location == "" {
+		meeting.Location = resourceLocation(slots[0].resources)
+	}
+	check := searchCheck(req)
+	check.known = slots[0].conflicts
+	err = store.Transaction(func(tx repository.Store) error {
+		return bookMeeting(tx, meeting, check)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifyAttendees(meeting, noticeInvite)
+
+	resp := meetingResponse(meeting)
+	resp.Conflicts = slots[0].conflicts
+	resp.UnavailableIds = slots[0].unavailable
+	return resp, nil
+}
+
+// searchSlots ranks slots for a one-off or recurring request. For recurring
+// requests it also returns the bounded RRULE that should be booked. Optional
+// attendees who are also required are dropped from req.OptionalIds.
+func searchSlots(req *repository.ScheduleRequest) ([]scoredSlot, string, error) {
+	// Someone listed as both required and optional is required
+	var optional []string
+	for _, userId := range req.OptionalIds {
+		if !contains(req.ParticipantIds, userId) && !contains(optional, userId) {
+			optional = append(optional, userId)
+		}
+	}
+	req.OptionalIds = optional
+
+	if err := validateScheduleRequest(*req); err != nil {
+		return nil, "", err
+	}
+	invited := append(append([]string{}, req.ParticipantIds...), req.OptionalIds...)
+	if req.OrganizerID != "" {
+		invited = append(invited, req.OrganizerID)
+	}
+	if err := requireUsers(invited); err != nil {
+		return nil, "", err
+	}
+	base, err := scoringBase(store, organizerOf(*req))
+	if err != nil {
+		return nil, "", err
+	}
+	scorer, err := scorerFor(*req, base)
+	if err != nil {
+		// Valid on the defaults, but not on top of the organization's options
+		return nil, "", apperr.Invalid("scoring", apperr.CodeInvalidValue, err.Error())
+	}
+	if req.Recurrence == "" {
+		slots, err := rankSlots(*req, scorer)
+		return slots, "", err
+	}
+	startTime, _ := time.Parse(time.RFC3339, req.TimeRange.Start)
+	rule, err := parseMeetingRecurrence(req.Recurrence, startTime.Location())
+	if err != nil {
+		return nil, "", err
+	}
+	slots, err := rankRecurringSlots(*req, rule, scorer)
+	return slots, rule.String(), err
+}
+
+// ProposeSlots runs the same search as ScheduleEvent but returns the best
+// scoring slots instead of booking one. Nothing is written to the database.
+func ProposeSlots(req repository.ProposalRequest) (*repository.ProposalResponse, error) {
+	slots, _, err := searchSlots(&req.ScheduleRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.MaxProposals
+	if limit <= 0 {
+		limit = defaultMaxProposals
+	}
+	if limit > len(slots) {
+		limit = len(slots)
+	}
+
+	proposals := make([]repository.SlotProposal, 0, limit)
+	for i, slot := range slots[:limit] {
+		proposals = append(proposals, repository.SlotProposal{
+			Rank:              i + 1,
+			StartTime:         slot.Start.Format(time.RFC3339),
+			EndTime:           slot.End.Format(time.RFC3339),
+			Score:             slot.score,
+			ParticipantScores: slot.participantScores,
+			Conflicts:         slot.conflicts,
+			UnavailableIds:    slot.unavailable,
+			ResourceIds:       slot.resources,
+		})
+	}
+
+	return &repository.ProposalResponse{
+		Title:          req.Title,
+		ParticipantIds: req.ParticipantIds,
+		Proposals:      proposals,
+	}, nil
+}
+
+const defaultMaxProposals = 3
+
+// missingOptionalPenalty is added to a slot's score for each optional
+// attendee who cannot make it. It outweighs a typical single-participant
+// score so that fuller attendance wins over a slightly nicer time.
+const missingOptionalPenalty = 5
+
+// scoringMargin widens the busy time loaded around the searched range so
+// that rules looking at neighbouring meetings and the whole day see them.
+const scoringMargin = 24 * time.Hour
+
+// rankSlots finds every conflict-free slot for the request and returns them
+// ordered from best (lowest score) to worst according to scorer.
+func rankSlots(req repository.ScheduleRequest, scorer scoring.Scorer) ([]scoredSlot, error) {
 	startTime, _ := time.Parse(time.RFC3339, req.TimeRange.Start)
 	endTime, _ := time.Parse(time.RFC3339, req.TimeRange.End)
 	slotDuration := time.Duration(req.DurationMinutes) * time.Minute
 
-	eventMap := make(map[string][]repository.Slot)
+	attendeeIds := append(append([]string{}, req.ParticipantIds...), req.OptionalIds...)
+	users, err := loadUsers(append([]string{organizerOf(req)}, attendeeIds...))
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := repository.Slot{Start: startTime.Add(-scoringMargin), End: endTime.Add(scoringMargin)}
+	eventMap, err := loadBusy(attendeeIds, loaded.Start, loaded.End, searchCheck(req))
+	if err != nil {
+		return nil, err
+	}
+	index := indexBusy(eventMap, loaded)
+	resources, err := planResources(req, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only required attendees can rule a slot out. Their busy time is merged
+	// once and starts are only generated in the free gaps.
+	var requiredBusy []repository.Slot
 	for _, userId := range req.ParticipantIds {
-		var events []model.Event
-		// Fix: Query for events that overlap with the time range
-		// An event overlaps if: event_start < range_end AND event_end > range_start
-		database.DB.Where("user_id = ? AND start_time < ? AND end_time > ?", userId, endTime, startTime).Find(&events)
-
-		log.Printf("User %s has %d existing events in range %v to %v", userId, len(events), startTime, endTime)
-		for _, e := range events {
-			log.Printf("  - Event: %s (%v to %v)", e.Title, e.StartTime, e.EndTime)
-			eventMap[userId] = append(eventMap[userId], repository.Slot{Start: e.StartTime, End: e.EndTime})
-		}
+		requiredBusy = append(requiredBusy, eventMap[userId]...)
 	}
+	window := repository.Slot{Start: startTime, End: endTime}
+	starts := freeStarts(requiredBusy, window, gridFor(req, users, startTime), slotDuration)
+	log.Printf("Found %d free starts from %v to %v with duration %v", len(starts), startTime, endTime, slotDuration)
 
-	// generate potential slots in 30-min steps within range
 	var candidateSlots []repository.Slot
-	log.Printf("Generating candidate slots from %v to %v with duration %v", startTime, endTime, slotDuration)
-
-	for t := startTime; t.Add(slotDuration).Before(endTime) || t.Add(slotDuration).Equal(endTime); t = t.Add(30 * time.Minute) {
+	var candidateResources [][]string
+	for _, t := range starts {
 		end := t.Add(slotDuration)
 		valid := true
-		log.Printf("Checking slot %v to %v", t, end)
-
-		for userId, events := range eventMap {
-			for _, ev := range events {
-				if ev.Start.Before(end) && ev.End.After(t) {
-					log.Printf("  Slot conflicts with %s's event: %v to %v", userId, ev.Start, ev.End)
-					valid = false
-					break
-				}
-			}
-			if !valid {
+		for _, userId := range req.ParticipantIds {
+			if !users[userId].Available(t, end) {
+				log.Printf("  Slot %v is outside %s's working hours", t, userId)
+				valid = false
 				break
 			}
 		}
+		if valid && req.MinQuorum > 0 {
+			missing := unavailableParticipants(repository.Slot{Start: t, End: end}, req.OptionalIds, index, users)
+			if attending := len(attendeeIds) - len(missing); attending < req.MinQuorum {
+				log.Printf("  Slot has only %d of the %d attendees needed for quorum", attending, req.MinQuorum)
+				valid = false
+			}
+		}
+		var booked []string
+		if valid {
+			if booked, valid = resources.pick(repository.Slot{Start: t, End: end}); !valid {
+				log.Printf("  Slot has no free room or equipment")
+			}
+		}
 		if valid {
-			log.Printf("  Slot %v to %v is VALID", t, end)
 			candidateSlots = append(candidateSlots, repository.Slot{Start: t, End: end})
+			candidateResources = append(candidateResources, booked)
 		}
 	}
 
 	log.Printf("Found %d candidate slots", len(candidateSlots))
 
 	if len(candidateSlots) == 0 {
-		return nil, errors.New("no available time slot found for all participants")
+		if len(req.ResourceIds) > 0 || req.Resource != nil {
+			return nil, fmt.Errorf("%w for all participants and resources", ErrNoSlotFound)
+		}
+		return nil, fmt.Errorf("%w for all participants", ErrNoSlotFound)
 	}
 
-	type scoredSlot struct {
-		repository.Slot
-		score int
-	}
 	var slots []scoredSlot
-	for _, slot := range candidateSlots {
+	for i, slot := range candidateSlots {
 		s := 0
-		for _, events := range eventMap {
-			s += ScoreSlot(slot, events)
+		breakdown := make(map[string]int, len(attendeeIds))
+		for _, userId := range req.ParticipantIds {
+			userScore := scorer.Score(slot, eventMap[userId], users[userId].Location())
+			breakdown[userId] = userScore
+			s += userScore
 		}
-		slots = append(slots, scoredSlot{slot, s})
+
+		// Reward slots where more optional attendees can make it
+		missing := unavailableParticipants(slot, req.OptionalIds, index, users)
+		for _, userId := range req.OptionalIds {
+			userScore := missingOptionalPenalty
+			if !contains(missing, userId) {
+				userScore = scorer.Score(slot, eventMap[userId], users[userId].Location())
+			}
+			breakdown[userId] = userScore
+			s += userScore
+		}
+		slots = append(slots, scoredSlot{Slot: slot, score: s, participantScores: breakdown, unavailable: missing, resources: candidateResources[i]})
 	}
 
-	sort.Slice(slots, func(i, j int) bool {
+	// Stable so that ties keep the earliest slot first
+	sort.SliceStable(slots, func(i, j int) bool {
 		return slots[i].score < slots[j].score
 	})
 
-	chosen := slots[0].Slot
-	meetingCode := "meeting-" + time.Now().Format("20060102150405")
+	return slots, nil
+}
 
-	// Use provided title or default to "New Meeting"
-	meetingTitle := req.Title
-	if meetingTitle == "" {
-		meetingTitle = "New Meeting"
-	}
+// loadBusy collects every participant's busy intervals overlapping
+// [startTime, endTime): their events, with series expanded, and active holds,
+// minus what check excludes.
+func loadBusy(userIds []string, startTime, endTime time.Time, check bookingCheck) (map[string][]repository.Slot, error) {
+	eventMap := make(map[string][]repository.Slot)
+	for _, userId := range userIds {
+		// Recurring series are expanded so each occurrence counts as busy
+		busy, err := busyIntervals(store, userId, startTime, endTime, check)
+		if err != nil {
+			return nil, storageFailure(err)
+		}
 
-	for _, userId := range req.ParticipantIds {
-		database.DB.Create(&model.Event{
-			EventCode: meetingCode + "-" + userId,
-			UserID:    userId,
-			Title:     meetingTitle,
-			StartTime: chosen.Start,
-			EndTime:   chosen.End,
-		})
+		log.Printf("User %s has %d busy intervals in range %v to %v", userId, len(busy), startTime, endTime)
+		for _, b := range busy {
+			log.Printf("  - Busy: %v to %v", b.Start, b.End)
+		}
+		eventMap[userId] = busy
 	}
 
-	return &repository.ScheduledMeetingResponse{
-		MeetingID:      meetingCode,
-		Title:          meetingTitle,
-		ParticipantIds: req.ParticipantIds,
-		StartTime:      chosen.Start.Format(time.RFC3339),
-		EndTime:        chosen.End.Format(time.RFC3339),
-	}, nil
+	return eventMap, nil
+}
+
+// loadUsers fetches the participants' profiles, including working hours,
+// keyed by user code. Participants without a user row are simply absent.
+func loadUsers(userIds []string) (map[string]model.User, error) {
+	if len(userIds) == 0 {
+		return map[string]model.User{}, nil
+	}
+	users, err := store.FindUsers(userIds)
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	byCode := make(map[string]model.User, len(users))
+	for _, u := range users {
+		// Working hours and scoring ask for the zone at every candidate
+		u.ResolveLocation()
+		byCode[u.UserCode] = u
+	}
+	return byCode, nil
 }
 
 func GetCalendarEvents(userId, start, end string) ([]model.Event, error) {
 
-	startTime, err := time.Parse(time.RFC3339, start)
-	if err != nil && start != "" {
-		return nil, errors.New("invalid start time format")
+	// Both bounds are optional
+	var v validator
+	var startTime, endTime time.Time
+	if start != "" {
+		startTime, _ = v.time("start", start)
 	}
-
-	endTime, err := time.Parse(time.RFC3339, end)
-	if err != nil && end != "" {
-		return nil, errors.New("invalid end time format")
+	if end != "" {
+		endTime, _ = v.time("end", end)
 	}
 
-	// Debug logging
-	log.Printf("GET /calendar/%s - start: %s, end: %s", userId, start, end)
-	log.Printf("Parsed times - start: %v, end: %v", startTime, endTime)
-
 	// Validate time range - if start time is after end time, return error
 	if !startTime.IsZero() && !endTime.IsZero() && startTime.After(endTime) {
 		log.Printf("Invalid time range: start (%v) is after end (%v)", startTime, endTime)
-		return nil, errors.New("invalid time range: start time cannot be after end time")
+		v.add("end", apperr.CodeOutOfRange, "cannot be before start")
 	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := findUser(store, userId); err != nil {
+		return nil, err
+	}
+
+	// Debug logging
+	log.Printf("GET /calendar/%s - start: %s, end: %s", userId, start, end)
+	log.Printf("Parsed times - start: %v, end: %v", startTime, endTime)
 
 	var events []model.Event
-	// Find events that overlap with the requested time range
-	// An event overlaps if: event_start < range_end AND event_end > range_start
-	var result *gorm.DB
+	var err error
 	if start == "" || end == "" {
-		result = db.DB.Where("user_id = ?", userId).Find(&events)
-
+		// Without a range series cannot be expanded, so return the stored rows
+		if events, err = store.UserEvents(userId); err == nil {
+			var meetings []model.Event
+			meetings, err = userMeetings(store, userId)
+			events = append(events, meetings...)
+			sortByStart(events)
+		}
 	} else {
-		result = db.DB.Where("user_id = ? AND start_time < ? AND end_time > ?", userId, endTime, startTime).Find(&events)
+		events, err = userEventsInRange(store, userId, startTime, endTime)
 	}
 
-	log.Printf("Query result - found %d events, error: %v", len(events), result.Error)
+	log.Printf("Query result - found %d events, error: %v", len(events), err)
 	for _, event := range events {
 		log.Printf("Event: %s (%s to %s)", event.Title, event.StartTime.Format(time.RFC3339), event.EndTime.Format(time.RFC3339))
 	}
 
-	if result.Error != nil {
-		return nil, result.Error
+	if err != nil {
+		return nil, storageFailure(err)
 	}
 
 	return events, nil
 }
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
 	"smart-scheduler/repository"
+	"strings"
 	"testing"
 	"time"
 )
@@ -218,3 +221,537 @@ func TestTimeRangeValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWorkingWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		window      repository.WorkingWindow
+		expectError bool
+		weekday     time.Weekday
+		start       int
+		end         int
+	}{
+		{
+			name:    "Regular day",
+			window:  repository.WorkingWindow{Weekday: "monday", Start: "09:00", End: "17:30"},
+			weekday: time.Monday,
+			start:   9 * 60,
+			end:     17*60 + 30,
+		},
+		{
+			name:    "Until midnight",
+			window:  repository.WorkingWindow{Weekday: "Friday", Start: "18:00", End: "24:00"},
+			weekday: time.Friday,
+			start:   18 * 60,
+			end:     24 * 60,
+		},
+		{
+			name:        "Unknown weekday",
+			window:      repository.WorkingWindow{Weekday: "someday", Start: "09:00", End: "17:00"},
+			expectError: true,
+		},
+		{
+			name:        "Malformed time",
+			window:      repository.WorkingWindow{Weekday: "monday", Start: "9am", End: "17:00"},
+			expectError: true,
+		},
+		{
+			name:        "End before start",
+			window:      repository.WorkingWindow{Weekday: "monday", Start: "17:00", End: "09:00"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wh, err := parseWorkingWindow(tt.window)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if wh.Weekday != tt.weekday || wh.StartMinute != tt.start || wh.EndMinute != tt.end {
+				t.Errorf("Unexpected window: %+v", wh)
+			}
+		})
+	}
+}
+
+func TestScoreSlotUsesParticipantTimezone(t *testing.T) {
+	// 10:00 IST is a morning slot locally but 04:30 in UTC
+	slot := repository.Slot{
+		Start: time.Date(2025, 8, 9, 10, 0, 0, 0, getISTTimezone()),
+		End:   time.Date(2025, 8, 9, 11, 0, 0, 0, getISTTimezone()),
+	}
+
+	if score := ScoreSlot(slot, nil, nil); score != 1 {
+		t.Errorf("Expected morning score 1 in the slot's own zone, got %d", score)
+	}
+	if score := ScoreSlot(slot, nil, time.UTC); score != 4 {
+		t.Errorf("Expected outside-hours score 4 in UTC, got %d", score)
+	}
+}
+
+func TestExpandSeries(t *testing.T) {
+	ist := getISTTimezone()
+	seriesStart := time.Date(2025, 8, 4, 9, 0, 0, 0, ist)
+	movedFrom := time.Date(2025, 8, 6, 9, 0, 0, 0, ist)
+	seriesId := uint(7)
+
+	master := model.Event{
+		ID:        seriesId,
+		EventCode: "standup",
+		UserID:    "user1",
+		Title:     "Standup",
+		StartTime: seriesStart,
+		EndTime:   seriesStart.Add(15 * time.Minute),
+		RRule:     "FREQ=DAILY;COUNT=5",
+	}
+	master.AddExDate(time.Date(2025, 8, 5, 9, 0, 0, 0, ist))
+
+	overrides := []model.Event{
+		{
+			ID:               8,
+			EventCode:        "standup-moved",
+			UserID:           "user1",
+			Title:            "Standup (late)",
+			StartTime:        time.Date(2025, 8, 6, 11, 0, 0, 0, ist),
+			EndTime:          time.Date(2025, 8, 6, 11, 15, 0, 0, ist),
+			RecurringEventID: &seriesId,
+			RecurrenceID:     &movedFrom,
+		},
+	}
+
+	events := expandSeries(master, overrides, seriesStart, seriesStart.AddDate(0, 0, 10))
+
+	// 5 occurrences, one excluded, one replaced by its override
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(events))
+	}
+
+	starts := make(map[string]string)
+	for _, e := range events {
+		starts[e.StartTime.Format(time.RFC3339)] = e.Title
+		if e.RecurringEventID == nil || *e.RecurringEventID != seriesId {
+			t.Errorf("Occurrence %s is not linked to its series", e.StartTime)
+		}
+	}
+	if _, ok := starts["2025-08-05T09:00:00+05:30"]; ok {
+		t.Error("Excluded occurrence should not be expanded")
+	}
+	if _, ok := starts["2025-08-06T09:00:00+05:30"]; ok {
+		t.Error("Overridden occurrence should not be expanded")
+	}
+	if starts["2025-08-06T11:00:00+05:30"] != "Standup (late)" {
+		t.Error("Override should replace the original occurrence")
+	}
+}
+
+func TestRetime(t *testing.T) {
+	start := time.Date(2025, 8, 9, 10, 0, 0, 0, getISTTimezone())
+	end := start.Add(time.Hour)
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		req         repository.EventUpdateRequest
+		expectStart string
+		expectEnd   string
+		expectError bool
+	}{
+		{
+			name:        "No time changes",
+			req:         repository.EventUpdateRequest{Title: str("Renamed")},
+			expectStart: "2025-08-09T10:00:00+05:30",
+			expectEnd:   "2025-08-09T11:00:00+05:30",
+		},
+		{
+			name:        "Moving the start keeps the duration",
+			req:         repository.EventUpdateRequest{StartTime: str("2025-08-09T14:00:00+05:30")},
+			expectStart: "2025-08-09T14:00:00+05:30",
+			expectEnd:   "2025-08-09T15:00:00+05:30",
+		},
+		{
+			name:        "Changing only the end",
+			req:         repository.EventUpdateRequest{EndTime: str("2025-08-09T10:30:00+05:30")},
+			expectStart: "2025-08-09T10:00:00+05:30",
+			expectEnd:   "2025-08-09T10:30:00+05:30",
+		},
+		{
+			name:        "End before start",
+			req:         repository.EventUpdateRequest{EndTime: str("2025-08-09T09:00:00+05:30")},
+			expectError: true,
+		},
+		{
+			name:        "Malformed start",
+			req:         repository.EventUpdateRequest{StartTime: str("tomorrow")},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, e, err := retime(start, end, tt.req)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if s.Format(time.RFC3339) != tt.expectStart || e.Format(time.RFC3339) != tt.expectEnd {
+				t.Errorf("Expected %s-%s, got %s-%s", tt.expectStart, tt.expectEnd, s.Format(time.RFC3339), e.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestParseMeetingRecurrence(t *testing.T) {
+	rule, err := parseMeetingRecurrence("FREQ=WEEKLY;COUNT=8", time.UTC)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rule.Count != 8 {
+		t.Errorf("Expected count 8, got %d", rule.Count)
+	}
+
+	// Open-ended patterns are capped
+	rule, err = parseMeetingRecurrence("FREQ=DAILY", time.UTC)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rule.Count != maxMeetingOccurrences {
+		t.Errorf("Expected open-ended rule to be capped at %d, got %d", maxMeetingOccurrences, rule.Count)
+	}
+
+	if _, err := parseMeetingRecurrence("FREQ=SOMETIMES", time.UTC); err == nil {
+		t.Error("Expected error for invalid recurrence")
+	}
+}
+
+func TestUnavailableParticipants(t *testing.T) {
+	ist := getISTTimezone()
+	slot := repository.Slot{
+		Start: time.Date(2025, 8, 9, 10, 0, 0, 0, ist),
+		End:   time.Date(2025, 8, 9, 11, 0, 0, 0, ist),
+	}
+	eventMap := map[string][]repository.Slot{
+		"user1": {{Start: time.Date(2025, 8, 9, 10, 30, 0, 0, ist), End: time.Date(2025, 8, 9, 11, 30, 0, 0, ist)}},
+		"user2": {{Start: time.Date(2025, 8, 9, 11, 0, 0, 0, ist), End: time.Date(2025, 8, 9, 12, 0, 0, 0, ist)}},
+	}
+	users := map[string]model.User{
+		"user3": {
+			UserCode: "user3",
+			WorkingHours: []model.WorkingHours{
+				{Weekday: time.Saturday, StartMinute: 14 * 60, EndMinute: 18 * 60},
+			},
+		},
+	}
+
+	day := repository.Slot{Start: time.Date(2025, 8, 9, 0, 0, 0, 0, ist), End: time.Date(2025, 8, 10, 0, 0, 0, 0, ist)}
+	busy := unavailableParticipants(slot, []string{"user1", "user2", "user3", "user4"}, indexBusy(eventMap, day), users)
+
+	// user1 overlaps, user2 is only adjacent, user3 is outside working hours
+	if len(busy) != 2 || busy[0] != "user1" || busy[1] != "user3" {
+		t.Errorf("Expected [user1 user3], got %v", busy)
+	}
+}
+
+func TestNewMeeting(t *testing.T) {
+	slot := repository.Slot{
+		Start: time.Date(2025, 8, 9, 10, 0, 0, 0, getISTTimezone()),
+		End:   time.Date(2025, 8, 9, 11, 0, 0, 0, getISTTimezone()),
+	}
+	req := repository.ScheduleRequest{
+		ParticipantIds: []string{"user2", "user3"},
+		Description:    "Quarterly planning",
+		Location:       "Room 4",
+	}
+
+	meeting := newMeeting("meeting-1", req, "Planning", slot, "")
+
+	// Organizer defaults to the first participant
+	if meeting.OrganizerID != "user2" {
+		t.Errorf("Expected organizer user2, got %s", meeting.OrganizerID)
+	}
+	if meeting.Description != "Quarterly planning" || meeting.Location != "Room 4" {
+		t.Errorf("Meeting details not copied: %+v", meeting)
+	}
+	if len(meeting.Attendees) != 2 {
+		t.Fatalf("Expected 2 attendees, got %d", len(meeting.Attendees))
+	}
+	for _, a := range meeting.Attendees {
+		if a.Status != model.RSVPNeedsAction {
+			t.Errorf("Expected attendee %s to await a response, got %s", a.UserID, a.Status)
+		}
+	}
+
+	req.OrganizerID = "user9"
+	if m := newMeeting("meeting-2", req, "Planning", slot, ""); m.OrganizerID != "user9" {
+		t.Errorf("Expected explicit organizer user9, got %s", m.OrganizerID)
+	}
+	// Optional attendees are invited but flagged as such
+	req.OptionalIds = []string{"user4"}
+	m := newMeeting("meeting-3", req, "Planning", slot, "")
+	if len(m.Attendees) != 3 || !m.Attendees[2].Optional || m.Attendees[2].UserID != "user4" {
+		t.Fatalf("Expected user4 as optional attendee, got %+v", m.Attendees)
+	}
+	if ids := m.RequiredIDs(); len(ids) != 2 {
+		t.Errorf("Expected 2 required attendees, got %v", ids)
+	}
+	if ids := m.OptionalIDs(); len(ids) != 1 || ids[0] != "user4" {
+		t.Errorf("Expected optional attendee user4, got %v", ids)
+	}
+}
+
+func TestSearchSlotsQuorum(t *testing.T) {
+	req := repository.ScheduleRequest{
+		ParticipantIds: []string{"user1"},
+		OptionalIds:    []string{"user2"},
+		MinQuorum:      3,
+	}
+	if _, _, err := searchSlots(&req); err == nil {
+		t.Error("Expected an error when the quorum exceeds the invited attendees")
+	}
+
+	// Required attendees listed as optional only count once
+	req = repository.ScheduleRequest{
+		ParticipantIds: []string{"user1"},
+		OptionalIds:    []string{"user1", "user2", "user2"},
+		MinQuorum:      3,
+	}
+	if _, _, err := searchSlots(&req); err == nil {
+		t.Error("Expected duplicate attendees not to count towards the quorum")
+	}
+	if len(req.OptionalIds) != 1 || req.OptionalIds[0] != "user2" {
+		t.Errorf("Expected optional attendees to be deduplicated, got %v", req.OptionalIds)
+	}
+}
+
+func TestSearchSlotsRejectsInvalidScoring(t *testing.T) {
+	req := repository.ScheduleRequest{
+		ParticipantIds: []string{"user1"},
+		Scoring:        &repository.ScoringOptions{Profile: "unknown"},
+	}
+	if _, _, err := searchSlots(&req); err == nil {
+		t.Error("Expected an error for an unknown scoring profile")
+	}
+}
+
+func TestValidateScheduleRequest(t *testing.T) {
+	valid := repository.ScheduleRequest{ParticipantIds: []string{"user1"}, DurationMinutes: 60}
+	valid.TimeRange.Start = "2025-08-09T09:00:00+05:30"
+	valid.TimeRange.End = "2025-08-09T17:00:00+05:30"
+	if err := validateScheduleRequest(valid); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(*repository.ScheduleRequest)
+		want   []apperr.FieldError
+	}{
+		{
+			name: "Everything missing",
+			change: func(r *repository.ScheduleRequest) {
+				*r = repository.ScheduleRequest{}
+			},
+			want: []apperr.FieldError{
+				{Path: "userIDs", Code: apperr.CodeRequired},
+				{Path: "durationMinutes", Code: apperr.CodeOutOfRange},
+				{Path: "timeRange.start", Code: apperr.CodeRequired},
+				{Path: "timeRange.end", Code: apperr.CodeRequired},
+			},
+		},
+		{
+			name: "Repeated and blank participants",
+			change: func(r *repository.ScheduleRequest) {
+				r.ParticipantIds = []string{"user1", " ", "user1"}
+			},
+			want: []apperr.FieldError{
+				{Path: "userIDs[1]", Code: apperr.CodeRequired},
+				{Path: "userIDs[2]", Code: apperr.CodeDuplicate},
+			},
+		},
+		{
+			name: "Malformed start",
+			change: func(r *repository.ScheduleRequest) {
+				r.TimeRange.Start = "tomorrow"
+			},
+			want: []apperr.FieldError{{Path: "timeRange.start", Code: apperr.CodeInvalidFormat}},
+		},
+		{
+			name: "End before start",
+			change: func(r *repository.ScheduleRequest) {
+				r.TimeRange.End = "2025-08-09T08:00:00+05:30"
+			},
+			want: []apperr.FieldError{{Path: "timeRange.end", Code: apperr.CodeOutOfRange}},
+		},
+		{
+			name: "Range shorter than the meeting",
+			change: func(r *repository.ScheduleRequest) {
+				r.DurationMinutes = 9 * 60
+			},
+			want: []apperr.FieldError{{Path: "timeRange", Code: apperr.CodeOutOfRange}},
+		},
+		{
+			name: "Negative duration",
+			change: func(r *repository.ScheduleRequest) {
+				r.DurationMinutes = -30
+			},
+			want: []apperr.FieldError{{Path: "durationMinutes", Code: apperr.CodeOutOfRange}},
+		},
+		{
+			name: "Odd step",
+			change: func(r *repository.ScheduleRequest) {
+				r.StepMinutes = 7
+			},
+			want: []apperr.FieldError{{Path: "stepMinutes", Code: apperr.CodeInvalidValue}},
+		},
+		{
+			name: "Recurring hold",
+			change: func(r *repository.ScheduleRequest) {
+				r.Recurrence, r.HoldMinutes = "FREQ=WEEKLY;COUNT=2", 15
+			},
+			want: []apperr.FieldError{{Path: "holdMinutes", Code: apperr.CodeNotSupported}},
+		},
+		{
+			name: "Malformed recurrence",
+			change: func(r *repository.ScheduleRequest) {
+				r.Recurrence = "FREQ=SOMETIMES"
+			},
+			want: []apperr.FieldError{{Path: "recurrence", Code: apperr.CodeInvalidFormat}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.change(&req)
+			err := validateScheduleRequest(req)
+			invalid, ok := err.(*apperr.ValidationError)
+			if !ok {
+				t.Fatalf("Expected a apperr.ValidationError, got %v", err)
+			}
+			if len(invalid.Fields) != len(tt.want) {
+				t.Fatalf("Expected %d field errors, got %+v", len(tt.want), invalid.Fields)
+			}
+			for i, want := range tt.want {
+				if got := invalid.Fields[i]; got.Path != want.Path || got.Code != want.Code || got.Message == "" {
+					t.Errorf("Expected %s %s, got %+v", want.Path, want.Code, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBookingCheckKnownConflicts(t *testing.T) {
+	occ := time.Date(2025, 8, 11, 10, 0, 0, 0, getISTTimezone())
+	check := bookingCheck{known: []repository.OccurrenceConflict{{
+		StartTime:      occ.Format(time.RFC3339),
+		EndTime:        occ.Add(time.Hour).Format(time.RFC3339),
+		ParticipantIds: []string{"user2"},
+	}}}
+
+	if !check.isKnown(occ.UTC(), "user2") {
+		t.Error("Expected the reported conflict to be known")
+	}
+	if check.isKnown(occ, "user3") {
+		t.Error("Expected another participant's conflict not to be known")
+	}
+	if check.isKnown(occ.AddDate(0, 0, 7), "user2") {
+		t.Error("Expected another occurrence's conflict not to be known")
+	}
+
+	err := error(&ConflictError{UserID: "user2", Start: occ, End: occ.Add(time.Hour)})
+	if !strings.Contains(err.Error(), "user2") {
+		t.Errorf("Expected the conflict to name the participant, got %q", err)
+	}
+}
+
+func TestMergeAndFreeIntervals(t *testing.T) {
+	base := time.Date(2025, 8, 9, 9, 0, 0, 0, getISTTimezone())
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	window := repository.Slot{Start: at(0), End: at(240)}
+
+	busy := []repository.Slot{
+		{Start: at(120), End: at(150)},
+		{Start: at(-30), End: at(30)},  // starts before the window
+		{Start: at(30), End: at(60)},   // touches the previous one
+		{Start: at(130), End: at(140)}, // inside another
+		{Start: at(230), End: at(300)}, // ends after the window
+		{Start: at(300), End: at(330)}, // outside the window
+	}
+	merged := mergeIntervals(busy, window)
+	expected := []repository.Slot{
+		{Start: at(0), End: at(60)},
+		{Start: at(120), End: at(150)},
+		{Start: at(230), End: at(240)},
+	}
+	if len(merged) != len(expected) {
+		t.Fatalf("Expected %d merged intervals, got %v", len(expected), merged)
+	}
+	for i := range expected {
+		if !merged[i].Start.Equal(expected[i].Start) || !merged[i].End.Equal(expected[i].End) {
+			t.Errorf("Interval %d: expected %v, got %v", i, expected[i], merged[i])
+		}
+	}
+
+	free := freeIntervals(merged, window)
+	if len(free) != 2 || !free[0].Start.Equal(at(60)) || !free[0].End.Equal(at(120)) || !free[1].End.Equal(at(230)) {
+		t.Errorf("Expected free time 60-120 and 150-230, got %v", free)
+	}
+	if free := freeIntervals(nil, window); len(free) != 1 || free[0] != window {
+		t.Errorf("Expected the whole window free, got %v", free)
+	}
+}
+
+func TestCandidateStarts(t *testing.T) {
+	ist := getISTTimezone()
+	start := time.Date(2025, 8, 9, 9, 7, 0, 0, ist)
+	end := time.Date(2025, 8, 9, 10, 30, 0, 0, ist)
+	aligned, unaligned := true, false
+	users := map[string]model.User{
+		"user1": {UserCode: "user1", Timezone: "Asia/Kolkata"},
+		// 09:07 IST is 03:37 UTC, so UTC quarter hours are 09:15 IST too
+		"user2": {UserCode: "user2", Timezone: "UTC"},
+		// Nepal is 15 minutes ahead of IST
+		"user3": {UserCode: "user3", Timezone: "Asia/Kathmandu"},
+	}
+
+	tests := []struct {
+		name      string
+		organizer string
+		step      int
+		align     *bool
+		want      []string
+	}{
+		{"server default", "user1", 0, nil, []string{"09:30", "10:00"}},
+		{"quarter hours", "user1", 15, &aligned, []string{"09:15", "09:30", "09:45", "10:00", "10:15"}},
+		{"unaligned", "user1", 30, &unaligned, []string{"09:07", "09:37", "10:07"}},
+		{"hourly", "user2", 60, &aligned, []string{"09:30"}},
+		{"organizer's zone", "user3", 30, &aligned, []string{"09:15", "09:45", "10:15"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := repository.ScheduleRequest{OrganizerID: tt.organizer, StepMinutes: tt.step, AlignToClock: tt.align}
+			var got []string
+			for _, s := range candidateStarts(req, users, start, end, 15*time.Minute) {
+				got = append(got, s.In(ist).Format("15:04"))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if err := validateStep(7); err == nil {
+		t.Error("Expected a 7 minute step to be rejected")
+	}
+}
@@ -1,51 +1,69 @@
 package service
 
 import (
+	"fmt"
 	"smart-scheduler/repository"
+	"smart-scheduler/scoring"
 	"time"
 )
 
-func ScoreSlot(slot repository.Slot, userEvents []repository.Slot) int {
-	score := 0
-	startHour := slot.Start.Hour()
-
-	// Prefer earlier slots (penalize late hours)
-	if startHour >= 16 {
-		score += 3
-	} else if startHour >= 12 {
-		score += 2
-	} else if startHour >= 9 {
-		score += 1
-	} else {
-		score += 4 // outside working hours
+// defaultScoring holds the server-wide scoring options. Requests can
+// override them with ScheduleRequest.Scoring.
+var defaultScoring repository.ScoringOptions
+
+// organizationScoring tunes defaultScoring for the meetings organized by
+// members of each organization, by name.
+var organizationScoring map[string]repository.ScoringOptions
+
+// SetDefaultScoring replaces the server-wide scoring options after checking
+// that they describe a valid scorer.
+func SetDefaultScoring(opts repository.ScoringOptions) error {
+	if _, err := scoring.Build(opts); err != nil {
+		return err
 	}
+	defaultScoring = opts
+	return nil
+}
 
-	// Buffer check (penalize if meeting is adjacent)
-	buffer := 15 * time.Minute
-	for _, event := range userEvents {
-		if absDuration(slot.Start.Sub(event.End)) < buffer || absDuration(event.Start.Sub(slot.End)) < buffer {
-			score += 2 // no buffer before/after
+// SetOrganizationScoring replaces the scoring options of each organization.
+// They are layered on the defaults like a request's own options, so call
+// SetDefaultScoring first.
+func SetOrganizationScoring(orgs map[string]repository.ScoringOptions) error {
+	for name, opts := range orgs {
+		if _, err := scoring.Build(scoring.Merge(defaultScoring, &opts)); err != nil {
+			return fmt.Errorf("organization %s: %w", name, err)
 		}
 	}
+	organizationScoring = orgs
+	return nil
+}
 
-	// Penalize small gaps before/after
-	for _, event := range userEvents {
-		gapBefore := slot.Start.Sub(event.End)
-		gapAfter := event.Start.Sub(slot.End)
-		if gapBefore > 0 && gapBefore < 30*time.Minute {
-			score += 1
-		}
-		if gapAfter > 0 && gapAfter < 30*time.Minute {
-			score += 1
-		}
+// scoringBase returns the options the organizer's organization uses, or the
+// defaults when it has none.
+func scoringBase(tx repository.Store, organizerId string) (repository.ScoringOptions, error) {
+	if len(organizationScoring) == 0 || organizerId == "" {
+		return defaultScoring, nil
+	}
+	organizer, err := findUser(tx, organizerId)
+	if err != nil {
+		return repository.ScoringOptions{}, err
+	}
+	opts, ok := organizationScoring[organizer.Organization]
+	if !ok {
+		return defaultScoring, nil
 	}
+	return scoring.Merge(defaultScoring, &opts), nil
+}
 
-	return score
+// scorerFor builds the scorer for req: base, the defaults of the
+// organizer's organization, with the request's own options layered on top.
+func scorerFor(req repository.ScheduleRequest, base repository.ScoringOptions) (scoring.Scorer, error) {
+	return scoring.Build(scoring.Merge(base, req.Scoring))
 }
 
-func absDuration(d time.Duration) time.Duration {
-	if d < 0 {
-		return -d
-	}
-	return d
+// ScoreSlot rates a candidate slot for one participant with the default
+// scoring rules; lower is better. Hour preferences are judged in loc, the
+// participant's own time zone, or in the slot's zone when loc is nil.
+func ScoreSlot(slot repository.Slot, userEvents []repository.Slot, loc *time.Location) int {
+	return scoring.Default().Score(slot, userEvents, loc)
 }
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+	"smart-scheduler/model"
+	"smart-scheduler/repository"
+	"sort"
+	"time"
+)
+
+// allowedStepMinutes are the candidate spacings offered. Each divides an
+// hour, so aligned starts land on the same boundaries every hour of the day.
+var allowedStepMinutes = []int{5, 10, 15, 30, 60}
+
+// The server's candidate spacing. Requests can override both with
+// StepMinutes and AlignToClock.
+var (
+	defaultStepMinutes = 30
+	defaultAlign       = true
+)
+
+// SetSlotDefaults sets how far apart candidate start times are and whether
+// they are aligned to wall-clock boundaries.
+func SetSlotDefaults(stepMinutes int, align bool) error {
+	if err := validateStep(stepMinutes); err != nil {
+		return err
+	}
+	defaultStepMinutes, defaultAlign = stepMinutes, align
+	return nil
+}
+
+func validateStep(stepMinutes int) error {
+	for _, allowed := range allowedStepMinutes {
+		if stepMinutes == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid step of %d minutes, expected one of %v", stepMinutes, allowedStepMinutes)
+}
+
+// slotStep returns the request's candidate spacing, or the server default.
+func slotStep(req repository.ScheduleRequest) time.Duration {
+	if req.StepMinutes > 0 {
+		return time.Duration(req.StepMinutes) * time.Minute
+	}
+	return time.Duration(defaultStepMinutes) * time.Minute
+}
+
+// slotGrid is the sequence of candidate start times first, first+step, ...
+type slotGrid struct {
+	first time.Time
+	step  time.Duration
+}
+
+// gridFor returns the request's grid of start times from start. Aligned
+// starts fall on multiples of the step past the hour in the organizer's
+// zone, so a range from 09:07 with 15-minute steps tries 09:15, 09:30 and so
+// on. Unaligned starts count from start itself.
+func gridFor(req repository.ScheduleRequest, users map[string]model.User, start time.Time) slotGrid {
+	step := slotStep(req)
+	align := defaultAlign
+	if req.AlignToClock != nil {
+		align = *req.AlignToClock
+	}
+
+	first := start
+	if align {
+		loc := start.Location()
+		if organizer, ok := users[organizerOf(req)]; ok && organizer.Location() != nil {
+			loc = organizer.Location()
+		}
+		local := start.In(loc)
+		past := time.Duration(local.Hour()*60+local.Minute())*time.Minute +
+			time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+		if rem := past % step; rem != 0 {
+			first = start.Add(step - rem)
+		}
+	}
+	return slotGrid{first: first, step: step}
+}
+
+// ceil returns the first grid time at or after t.
+func (g slotGrid) ceil(t time.Time) time.Time {
+	if !t.After(g.first) {
+		return g.first
+	}
+	n := (t.Sub(g.first) + g.step - 1) / g.step
+	return g.first.Add(n * g.step)
+}
+
+// candidateStarts returns every grid time t with [t, t+duration) inside
+// [start, end).
+func candidateStarts(req repository.ScheduleRequest, users map[string]model.User, start, end time.Time, duration time.Duration) []time.Time {
+	grid := gridFor(req, users, start)
+	var starts []time.Time
+	for t := grid.first; !t.Add(duration).After(end); t = t.Add(grid.step) {
+		starts = append(starts, t)
+	}
+	return starts
+}
+
+// freeStarts returns the grid times t in window where [t, t+duration) misses
+// every busy interval. The busy time of all participants is merged once and
+// only the free gaps between merged intervals are walked, so the cost is
+// O(n log n) in the number of busy intervals plus the starts returned,
+// instead of checking every step against every event.
+func freeStarts(busy []repository.Slot, window repository.Slot, grid slotGrid, duration time.Duration) []time.Time {
+	var starts []time.Time
+	for _, gap := range freeIntervals(mergeIntervals(busy, window), window) {
+		for t := grid.ceil(gap.Start); !t.Add(duration).After(gap.End); t = t.Add(grid.step) {
+			starts = append(starts, t)
+		}
+	}
+	return starts
+}
+
+// busyIndex holds each participant's busy time merged and in start order,
+// so whether a slot is busy is a binary search instead of a scan of every
+// event. Recurring searches ask this once per occurrence of every candidate.
+type busyIndex map[string][]repository.Slot
+
+// indexBusy merges every participant's busy intervals inside window.
+func indexBusy(eventMap map[string][]repository.Slot, window repository.Slot) busyIndex {
+	index := make(busyIndex, len(eventMap))
+	for userId, busy := range eventMap {
+		index[userId] = mergeIntervals(busy, window)
+	}
+	return index
+}
+
+// busy reports whether any of userId's busy time overlaps slot.
+func (b busyIndex) busy(userId string, slot repository.Slot) bool {
+	merged := b[userId]
+	i := sort.Search(len(merged), func(i int) bool { return merged[i].End.After(slot.Start) })
+	return i < len(merged) && merged[i].Start.Before(slot.End)
+}
+
+// organizerOf is the request's organizer, defaulting to the first
+// participant.
+func organizerOf(req repository.ScheduleRequest) string {
+	if req.OrganizerID == "" && len(req.ParticipantIds) > 0 {
+		return req.ParticipantIds[0]
+	}
+	return req.OrganizerID
+}
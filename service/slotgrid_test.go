@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"smart-scheduler/repository"
+	"testing"
+	"time"
+)
+
+// nestedLoopFreeStarts is the candidate generator freeStarts replaced: every
+// grid step is checked against every event of every participant.
+func nestedLoopFreeStarts(eventMap map[string][]repository.Slot, userIds []string, window repository.Slot, grid slotGrid, duration time.Duration) []time.Time {
+	var starts []time.Time
+	for t := grid.first; !t.Add(duration).After(window.End); t = t.Add(grid.step) {
+		slot := repository.Slot{Start: t, End: t.Add(duration)}
+		free := true
+		for _, userId := range userIds {
+			for _, ev := range eventMap[userId] {
+				if ev.Overlaps(slot) {
+					free = false
+					break
+				}
+			}
+			if !free {
+				break
+			}
+		}
+		if free {
+			starts = append(starts, t)
+		}
+	}
+	return starts
+}
+
+// randomCalendars gives each participant a few events of 15 minutes to two
+// hours on every day of [from, from+days), between 08:00 and 19:00.
+func randomCalendars(seed int64, participants, days int, from time.Time) (map[string][]repository.Slot, []string) {
+	rng := rand.New(rand.NewSource(seed))
+	eventMap := make(map[string][]repository.Slot, participants)
+	userIds := make([]string, 0, participants)
+	for p := 0; p < participants; p++ {
+		userId := fmt.Sprintf("user%d", p)
+		userIds = append(userIds, userId)
+		for d := 0; d < days; d++ {
+			day := from.AddDate(0, 0, d)
+			for e := rng.Intn(4); e > 0; e-- {
+				start := day.Add(8*time.Hour + time.Duration(rng.Intn(44))*15*time.Minute)
+				eventMap[userId] = append(eventMap[userId], repository.Slot{
+					Start: start,
+					End:   start.Add(time.Duration(1+rng.Intn(8)) * 15 * time.Minute),
+				})
+			}
+		}
+	}
+	return eventMap, userIds
+}
+
+func flatten(eventMap map[string][]repository.Slot, userIds []string) []repository.Slot {
+	var busy []repository.Slot
+	for _, userId := range userIds {
+		busy = append(busy, eventMap[userId]...)
+	}
+	return busy
+}
+
+func TestFreeStartsMatchesNestedLoop(t *testing.T) {
+	from := time.Date(2025, 8, 4, 0, 0, 0, 0, getISTTimezone())
+	for seed := int64(1); seed <= 20; seed++ {
+		eventMap, userIds := randomCalendars(seed, 1+int(seed)%6, 7, from)
+		window := repository.Slot{Start: from.Add(7*time.Hour + 7*time.Minute), End: from.AddDate(0, 0, 7)}
+		for _, step := range []time.Duration{5 * time.Minute, 30 * time.Minute} {
+			grid := slotGrid{first: window.Start, step: step}
+			for _, duration := range []time.Duration{15 * time.Minute, time.Hour} {
+				want := nestedLoopFreeStarts(eventMap, userIds, window, grid, duration)
+				got := freeStarts(flatten(eventMap, userIds), window, grid, duration)
+				if len(got) != len(want) {
+					t.Fatalf("seed %d, step %v, duration %v: expected %d starts, got %d", seed, step, duration, len(want), len(got))
+				}
+				for i := range want {
+					if !got[i].Equal(want[i]) {
+						t.Fatalf("seed %d, step %v, duration %v: start %d is %v, expected %v", seed, step, duration, i, got[i], want[i])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBusyIndexMatchesScan(t *testing.T) {
+	from := time.Date(2025, 8, 4, 0, 0, 0, 0, getISTTimezone())
+	window := repository.Slot{Start: from, End: from.AddDate(0, 0, 7)}
+	for seed := int64(1); seed <= 20; seed++ {
+		eventMap, userIds := randomCalendars(seed, 3, 7, from)
+		index := indexBusy(eventMap, window)
+		for t0 := window.Start; t0.Before(window.End); t0 = t0.Add(10 * time.Minute) {
+			slot := repository.Slot{Start: t0, End: t0.Add(25 * time.Minute)}
+			for _, userId := range userIds {
+				want := false
+				for _, ev := range eventMap[userId] {
+					want = want || ev.Overlaps(slot)
+				}
+				if got := index.busy(userId, slot); got != want {
+					t.Fatalf("seed %d, %s at %v: busy is %v, expected %v", seed, userId, t0, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestSlotGridCeil(t *testing.T) {
+	first := time.Date(2025, 8, 9, 9, 15, 0, 0, getISTTimezone())
+	grid := slotGrid{first: first, step: 15 * time.Minute}
+	for _, tt := range []struct{ at, want time.Duration }{
+		{-time.Hour, 0},
+		{0, 0},
+		{time.Minute, 15 * time.Minute},
+		{15 * time.Minute, 15 * time.Minute},
+		{31 * time.Minute, 45 * time.Minute},
+	} {
+		if got := grid.ceil(first.Add(tt.at)); !got.Equal(first.Add(tt.want)) {
+			t.Errorf("ceil(first%+v) = %v, expected first+%v", tt.at, got, tt.want)
+		}
+	}
+}
+
+// Compare the two generators on a month-long range:
+//
+//	go test ./service -run '^$' -bench FreeStarts
+func BenchmarkFreeStarts(b *testing.B) {
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, getISTTimezone())
+	for _, size := range []struct{ participants, days int }{{5, 7}, {50, 7}, {50, 31}} {
+		eventMap, userIds := randomCalendars(1, size.participants, size.days, from)
+		window := repository.Slot{Start: from, End: from.AddDate(0, 0, size.days)}
+		grid := slotGrid{first: from, step: 15 * time.Minute}
+		name := fmt.Sprintf("%dparticipants/%ddays", size.participants, size.days)
+
+		b.Run("sweep/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				freeStarts(flatten(eventMap, userIds), window, grid, 30*time.Minute)
+			}
+		})
+		b.Run("nested/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				nestedLoopFreeStarts(eventMap, userIds, window, grid, 30*time.Minute)
+			}
+		})
+	}
+}
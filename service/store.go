@@ -0,0 +1,24 @@
+package service
+
+import (
+	"errors"
+	"smart-scheduler/apperr"
+	"smart-scheduler/repository"
+)
+
+// store is where the service keeps its data. It is set once at start-up.
+var store repository.Store
+
+// SetStore selects the storage backend used by every service function.
+func SetStore(s repository.Store) {
+	store = s
+}
+
+// storageFailure wraps an error from the store in a StorageError, once.
+func storageFailure(err error) error {
+	var storageErr *apperr.StorageError
+	if err == nil || errors.As(err, &storageErr) {
+		return err
+	}
+	return &apperr.StorageError{Err: err}
+}
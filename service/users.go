@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"smart-scheduler/apperr"
+	"smart-scheduler/model"
+	"smart-scheduler/repository"
+	"strings"
+)
+
+var ErrUserExists = apperr.New(apperr.Kind{Code: "user_exists", Title: "User already exists", Status: http.StatusConflict}, "user already exists")
+
+// Page sizes for ListUsers
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// codePattern keeps user and resource codes safe to use in URL paths.
+var codePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// CreateUser adds a user. The user code must be unused.
+func CreateUser(req repository.UserDetails) (*repository.UserDetails, error) {
+	var v validator
+	v.code("userCode", req.UserCode)
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		v.add("name", apperr.CodeRequired, "is required")
+	}
+	validateEmail(&v, "email", req.Email)
+	validateTimezone(&v, "timezone", req.Timezone)
+	hours := parseWorkingWindows(&v, req.UserCode, req.WorkingHours)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		UserCode:     req.UserCode,
+		Name:         req.Name,
+		Email:        req.Email,
+		Timezone:     req.Timezone,
+		Organization: strings.TrimSpace(req.Organization),
+		WorkingHours: hours,
+	}
+	err := store.Transaction(func(tx repository.Store) error {
+		_, err := tx.FindUser(user.UserCode)
+		if err == nil {
+			return fmt.Errorf("%w: %s", ErrUserExists, user.UserCode)
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return storageFailure(err)
+		}
+		// A concurrent request may have taken the code since the lookup
+		err = tx.CreateUser(user)
+		if errors.Is(err, repository.ErrDuplicate) {
+			return fmt.Errorf("%w: %s", ErrUserExists, user.UserCode)
+		}
+		return storageFailure(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Created user %s", user.UserCode)
+	return toUserDetails(*user), nil
+}
+
+func GetUser(userCode string) (*repository.UserDetails, error) {
+	user, err := findUser(store, userCode)
+	if err != nil {
+		return nil, err
+	}
+	return toUserDetails(*user), nil
+}
+
+// ListUsers returns one page of users, ordered by user code, whose names
+// contain search. Pages count from 1.
+func ListUsers(search string, page, pageSize int) (*repository.UserList, error) {
+	var v validator
+	if page < 1 {
+		v.add("page", apperr.CodeOutOfRange, "must be at least 1, pages count from 1")
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize < 1 || pageSize > maxPageSize {
+		v.add("pageSize", apperr.CodeOutOfRange, "must be from 1 to %d", maxPageSize)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	users, total, err := store.ListUsers(strings.TrimSpace(search), (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, storageFailure(err)
+	}
+	list := &repository.UserList{Users: []repository.UserDetails{}, Page: page, PageSize: pageSize, Total: total}
+	for _, u := range users {
+		list.Users = append(list.Users, *toUserDetails(u))
+	}
+	return list, nil
+}
+
+// UpdateUser changes the fields set in req. The user code cannot change,
+// since events and meetings refer to it.
+func UpdateUser(userCode string, req repository.UserUpdateRequest) (*repository.UserDetails, error) {
+	var v validator
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		v.add("name", apperr.CodeRequired, "cannot be empty")
+	}
+	if req.Email != nil {
+		validateEmail(&v, "email", *req.Email)
+	}
+	if req.Timezone != nil {
+		validateTimezone(&v, "timezone", *req.Timezone)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	var user *model.User
+	err := store.Transaction(func(tx repository.Store) error {
+		var err error
+		if user, err = findUser(tx, userCode); err != nil {
+			return err
+		}
+		if req.Name != nil {
+			user.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.Email != nil {
+			user.Email = *req.Email
+		}
+		if req.Timezone != nil {
+			user.Timezone = *req.Timezone
+		}
+		if req.Organization != nil {
+			user.Organization = strings.TrimSpace(*req.Organization)
+		}
+		return storageFailure(tx.UpdateUser(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toUserDetails(*user), nil
+}
+
+// DeleteUser removes the user with their events, holds and working hours,
+// and takes them off every meeting they were invited to.
+func DeleteUser(userCode string) error {
+	err := store.Transaction(func(tx repository.Store) error {
+		return tx.DeleteUser(userCode)
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, userCode)
+	}
+	if err != nil {
+		return storageFailure(err)
+	}
+	log.Printf("Deleted user %s", userCode)
+	return nil
+}
+
+func findUser(tx repository.Store, userCode string) (*model.User, error) {
+	user, err := tx.FindUser(userCode)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, userCode)
+	}
+	return user, storageFailure(err)
+}
+
+// requireUsers returns ErrUserNotFound naming the first of userIds that
+// has no user.
+func requireUsers(userIds []string) error {
+	users, err := loadUsers(userIds)
+	if err != nil {
+		return err
+	}
+	for _, userId := range userIds {
+		if _, ok := users[userId]; !ok {
+			return fmt.Errorf("%w: %s", ErrUserNotFound, userId)
+		}
+	}
+	return nil
+}
+
+func toUserDetails(user model.User) *repository.UserDetails {
+	return &repository.UserDetails{
+		UserCode:     user.UserCode,
+		Name:         user.Name,
+		Email:        user.Email,
+		Timezone:     user.Timezone,
+		Organization: user.Organization,
+		WorkingHours: toWorkingWindows(user.WorkingHours),
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"smart-scheduler/apperr"
+	"smart-scheduler/repository"
+	"strings"
+	"time"
+)
+
+type validator struct {
+	fields []apperr.FieldError
+}
+
+func (v *validator) add(path, code, format string, args ...interface{}) {
+	v.fields = append(v.fields, apperr.FieldError{Path: path, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns a ValidationError when any field failed, or nil.
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &apperr.ValidationError{Fields: v.fields}
+}
+
+// time parses an RFC 3339 field that must be present.
+func (v *validator) time(path, value string) (time.Time, bool) {
+	if value == "" {
+		v.add(path, apperr.CodeRequired, "is required")
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		v.add(path, apperr.CodeInvalidFormat, "must be an RFC 3339 time such as 2025-08-09T09:00:00+05:30")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// ids checks that a list of codes has no blank or repeated entries.
+func (v *validator) ids(path string, ids []string) {
+	for i, id := range ids {
+		if strings.TrimSpace(id) == "" {
+			v.add(fmt.Sprintf("%s[%d]", path, i), apperr.CodeRequired, "must not be blank")
+		} else if contains(ids[:i], id) {
+			v.add(fmt.Sprintf("%s[%d]", path, i), apperr.CodeDuplicate, "repeats %q", id)
+		}
+	}
+}
+
+// code checks a user or resource code against codePattern.
+func (v *validator) code(path, value string) {
+	if value == "" {
+		v.add(path, apperr.CodeRequired, "is required")
+	} else if !codePattern.MatchString(value) {
+		v.add(path, apperr.CodeInvalidFormat, "must be up to 64 letters, digits, \"-\" or \"_\"")
+	}
+}
+
+// validateScheduleRequest checks the shape of a schedule, proposal or
+// reschedule search before anything is looked up. Unknown users and
+// resources are reported separately, as they depend on stored data.
+func validateScheduleRequest(req repository.ScheduleRequest) error {
+	var v validator
+
+	if len(req.ParticipantIds) == 0 {
+		v.add("userIDs", apperr.CodeRequired, "must list at least one participant")
+	}
+	v.ids("userIDs", req.ParticipantIds)
+	v.ids("optionalUserIDs", req.OptionalIds)
+	v.ids("resourceIDs", req.ResourceIds)
+
+	if req.DurationMinutes <= 0 {
+		v.add("durationMinutes", apperr.CodeOutOfRange, "must be a positive number of minutes")
+	}
+	start, startOK := v.time("timeRange.start", req.TimeRange.Start)
+	end, endOK := v.time("timeRange.end", req.TimeRange.End)
+	if startOK && endOK {
+		if !end.After(start) {
+			v.add("timeRange.end", apperr.CodeOutOfRange, "must be after timeRange.start")
+		} else if req.DurationMinutes > 0 && end.Sub(start) < time.Duration(req.DurationMinutes)*time.Minute {
+			v.add("timeRange", apperr.CodeOutOfRange, "is shorter than durationMinutes")
+		}
+	}
+
+	if req.StepMinutes != 0 {
+		if err := validateStep(req.StepMinutes); err != nil {
+			v.add("stepMinutes", apperr.CodeInvalidValue, "must be one of %v", allowedStepMinutes)
+		}
+	}
+	if req.MinQuorum < 0 {
+		v.add("minQuorum", apperr.CodeOutOfRange, "cannot be negative")
+	} else if req.MinQuorum > len(req.ParticipantIds)+len(req.OptionalIds) {
+		v.add("minQuorum", apperr.CodeOutOfRange, "is larger than the number of invited attendees")
+	}
+	if _, err := scorerFor(req, defaultScoring); err != nil {
+		v.add("scoring", apperr.CodeInvalidValue, "%v", err)
+	}
+
+	if req.HoldMinutes < 0 {
+		v.add("holdMinutes", apperr.CodeOutOfRange, "cannot be negative")
+	}
+	if req.Recurrence != "" {
+		if _, err := parseMeetingRecurrence(req.Recurrence, start.Location()); err != nil {
+			v.add("recurrence", apperr.CodeInvalidFormat, "%v", err)
+		}
+		if req.HoldMinutes > 0 {
+			v.add("holdMinutes", apperr.CodeNotSupported, "tentative holds are not supported for recurring meetings")
+		}
+		if len(req.ResourceIds) > 0 || req.Resource != nil {
+			v.add("recurrence", apperr.CodeNotSupported, "rooms and equipment can only be booked for one-off meetings")
+		}
+	}
+	if req.HoldMinutes > 0 && (len(req.ResourceIds) > 0 || req.Resource != nil) {
+		v.add("holdMinutes", apperr.CodeNotSupported, "tentative holds cannot reserve rooms or equipment")
+	}
+	if req.Resource != nil && req.Resource.MinCapacity < 0 {
+		v.add("resource.minCapacity", apperr.CodeOutOfRange, "cannot be negative")
+	}
+
+	return v.err()
+}